@@ -0,0 +1,84 @@
+package cpu
+
+import (
+	"context"
+	"time"
+)
+
+// BreakdownStat holds the share of an interval spent in every TimesStat
+// category, in percent. Guest and GuestNice are already contained in the
+// kernel's user and nice counters, so they are subtracted from User and Nice
+// here and reported on their own; all fields add up to 100.
+type BreakdownStat struct {
+	CPU       string  `json:"cpu"`
+	User      float64 `json:"user"`
+	System    float64 `json:"system"`
+	Idle      float64 `json:"idle"`
+	Nice      float64 `json:"nice"`
+	Iowait    float64 `json:"iowait"`
+	Irq       float64 `json:"irq"`
+	Softirq   float64 `json:"softirq"`
+	Steal     float64 `json:"steal"`
+	Guest     float64 `json:"guest"`
+	GuestNice float64 `json:"guestNice"`
+	// Reset is set if a counter of the CPU went backwards between the
+	// samples, all percentages are 0 then.
+	Reset bool `json:"reset"`
+}
+
+// calculateBreakdown splits the ticks between t1 and t2 up. If a counter
+// went backwards the result is all 0 with Reset set.
+func calculateBreakdown(t1, t2 TicksStat) BreakdownStat {
+	ret := BreakdownStat{CPU: t2.CPU}
+
+	delta, err := t2.Sub(t1)
+	if err != nil {
+		ret.Reset = true
+		return ret
+	}
+	total := delta.Total()
+	if total == 0 {
+		return ret
+	}
+	percent := func(ticks uint64) float64 {
+		return float64(ticks) / float64(total) * 100
+	}
+	// guest time is accounted to user and nice at the same time, but the
+	// lines are read one after the other
+	withoutGuest := func(ticks, guest uint64) uint64 {
+		if ticks < guest {
+			return 0
+		}
+		return ticks - guest
+	}
+
+	ret.User = percent(withoutGuest(delta.User, delta.Guest))
+	ret.Nice = percent(withoutGuest(delta.Nice, delta.GuestNice))
+	ret.Guest = percent(delta.Guest)
+	ret.GuestNice = percent(delta.GuestNice)
+	ret.System = percent(delta.System)
+	ret.Idle = percent(delta.Idle)
+	ret.Iowait = percent(delta.Iowait)
+	ret.Irq = percent(delta.Irq)
+	ret.Softirq = percent(delta.Softirq)
+	ret.Steal = percent(delta.Steal)
+	return ret
+}
+
+func calculateAllBreakdown(pairs cpuPairs) []BreakdownStat {
+	ret := make([]BreakdownStat, len(pairs.second))
+	for i := range pairs.second {
+		ret[i] = calculateBreakdown(pairs.first[i], pairs.second[i])
+	}
+	return ret
+}
+
+// BreakdownWithContext samples the CPU times like PercentWithContext does and
+// returns how the interval was split between the individual categories.
+func BreakdownWithContext(ctx context.Context, percpu bool) ([]BreakdownStat, error) {
+	return defaultCollector.BreakdownWithContext(ctx, percpu)
+}
+
+func BreakdownInterval(ctx context.Context, interval time.Duration, percpu bool) ([]BreakdownStat, error) {
+	return defaultCollector.BreakdownInterval(ctx, interval, percpu)
+}
@@ -0,0 +1,52 @@
+package cpu
+
+import (
+	"context"
+	"fmt"
+)
+
+// CacheStat describes one CPU cache. Caches shared by several logical CPUs
+// are reported once, with all of them in SharedCPUs.
+type CacheStat struct {
+	Level int `json:"level"`
+	// Type is "Data", "Instruction" or "Unified".
+	Type string `json:"type"`
+	// Size is in bytes.
+	Size       uint64 `json:"size"`
+	LineSize   int    `json:"lineSize"`
+	Ways       int    `json:"ways"`
+	Sets       int    `json:"sets"`
+	SharedCPUs Set    `json:"sharedCpus"`
+}
+
+// Name returns the usual short name of the cache, such as "L1d" or "L3".
+func (c CacheStat) Name() string {
+	switch c.Type {
+	case "Data":
+		return fmt.Sprintf("L%dd", c.Level)
+	case "Instruction":
+		return fmt.Sprintf("L%di", c.Level)
+	default:
+		return fmt.Sprintf("L%d", c.Level)
+	}
+}
+
+func Caches() ([]CacheStat, error) {
+	return CachesWithContext(context.Background())
+}
+
+func CachesWithContext(ctx context.Context) ([]CacheStat, error) {
+	return defaultCollector.CachesWithContext(ctx)
+}
+
+// CachesForCPU returns the caches in caches that serve the logical CPU cpu,
+// from L1 upwards.
+func CachesForCPU(caches []CacheStat, cpu int) []CacheStat {
+	var ret []CacheStat
+	for _, c := range caches {
+		if c.SharedCPUs.Contains(cpu) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
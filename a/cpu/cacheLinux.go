@@ -0,0 +1,106 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func (c *Collector) CachesWithContext(ctx context.Context) ([]CacheStat, error) {
+	root := c.Root()
+
+	var ret []CacheStat
+	seen := make(map[string]bool)
+	for _, cpu := range sysCPUNumbers(root) {
+		dirs, err := root.Glob(sysCPUPath(root, cpu, "cache/index[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			cache, err := readCache(root, dir)
+			if err != nil {
+				return nil, err
+			}
+			// every CPU sharing the cache lists it again
+			key := fmt.Sprintf("%d/%s/%s", cache.Level, cache.Type, cache.SharedCPUs)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ret = append(ret, cache)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Level != ret[j].Level {
+			return ret[i].Level < ret[j].Level
+		}
+		if ret[i].Type != ret[j].Type {
+			return ret[i].Type < ret[j].Type
+		}
+		return ret[i].SharedCPUs.First() < ret[j].SharedCPUs.First()
+	})
+	return ret, nil
+}
+
+func readCache(root Root, dir string) (CacheStat, error) {
+	var ret CacheStat
+	var err error
+
+	if ret.Level, err = readCacheInt(root, dir, "level"); err != nil {
+		return ret, err
+	}
+	if ret.Type, err = root.ReadString(path.Join(dir, "type")); err != nil {
+		return ret, err
+	}
+	if size, err := root.ReadString(path.Join(dir, "size")); err == nil {
+		if ret.Size, err = parseCacheSize(size); err != nil {
+			return ret, err
+		}
+	}
+	// not every architecture exports these
+	ret.LineSize, _ = readCacheInt(root, dir, "coherency_line_size")
+	ret.Ways, _ = readCacheInt(root, dir, "ways_of_associativity")
+	ret.Sets, _ = readCacheInt(root, dir, "number_of_sets")
+
+	sharedList, err := root.ReadString(path.Join(dir, "shared_cpu_list"))
+	if err != nil {
+		return ret, err
+	}
+	if ret.SharedCPUs, err = ParseSet(sharedList); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+func readCacheInt(root Root, dir, name string) (int, error) {
+	value, err := root.ReadString(path.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// parseCacheSize parses sizes like "48K" or "32M" into bytes.
+func parseCacheSize(s string) (uint64, error) {
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	n, err := strconv.ParseUint(strings.TrimRight(s, "KMG"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) CachesWithContext(ctx context.Context) ([]CacheStat, error) {
+	return nil, ErrorNotImplemented
+}
@@ -0,0 +1,83 @@
+package cpu
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrorNoCgroup = errors.New("could not find the cgroup of the current process")
+
+// CgroupStat describes the CPU controller of the cgroup the current process
+// belongs to.
+type CgroupStat struct {
+	Version int    `json:"version"`
+	Path    string `json:"path"`
+	// Usage is the CPU time consumed by the cgroup, in seconds.
+	Usage float64 `json:"usage"`
+	// Quota is the number of CPUs the cgroup may use per period, 0 if unlimited.
+	Quota float64 `json:"quota"`
+}
+
+// CgroupPercentStat is the CPU usage of the current cgroup over an interval.
+type CgroupPercentStat struct {
+	// Used is the average number of CPUs busy during the interval.
+	Used float64 `json:"used"`
+	// Limit is the cgroup quota, or the logical CPU count if there is none.
+	Limit float64 `json:"limit"`
+	// Percent is Used relative to Limit.
+	Percent float64 `json:"percent"`
+}
+
+func Cgroup() (*CgroupStat, error) {
+	return CgroupWithContext(context.Background())
+}
+
+func CgroupWithContext(ctx context.Context) (*CgroupStat, error) {
+	return defaultCollector.CgroupWithContext(ctx)
+}
+
+func CgroupPercentWithContext(ctx context.Context) (*CgroupPercentStat, error) {
+	return defaultCollector.CgroupPercentWithContext(ctx)
+}
+
+func CgroupPercentInterval(ctx context.Context, interval time.Duration) (*CgroupPercentStat, error) {
+	return defaultCollector.CgroupPercentInterval(ctx, interval)
+}
+
+func (c *Collector) CgroupPercentWithContext(ctx context.Context) (*CgroupPercentStat, error) {
+	return c.CgroupPercentInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// CgroupPercentInterval samples the cgroup CPU usage twice, interval apart.
+// If interval is 0 or less the value is relative to the previous call.
+func (c *Collector) CgroupPercentInterval(ctx context.Context, interval time.Duration) (*CgroupPercentStat, error) {
+	cg1, cg2, err := SampleInterval(ctx, interval, &c.last.cgroup, func() (*CgroupStat, error) {
+		return c.CgroupWithContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.calculateCgroupPercent(ctx, cg2.Value, cg1.Value.Usage, cg2.Value.Usage, cg2.At.Sub(cg1.At))
+}
+
+func (c *Collector) calculateCgroupPercent(ctx context.Context, cg *CgroupStat, usage1, usage2 float64, elapsed time.Duration) (*CgroupPercentStat, error) {
+	ret := &CgroupPercentStat{Limit: cg.Quota}
+	if ret.Limit == 0 {
+		n, err := c.countsWithContext(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		ret.Limit = float64(n)
+	}
+	// the cgroup was recreated or the root changed in between
+	if usage2 < usage1 {
+		return nil, ErrorCounterDecreased
+	}
+	if elapsed <= 0 || ret.Limit == 0 {
+		return ret, nil
+	}
+	ret.Used = (usage2 - usage1) / elapsed.Seconds()
+	ret.Percent = ret.Used / ret.Limit * 100
+	return ret, nil
+}
@@ -0,0 +1,203 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// cgroupPaths maps the controllers listed in /proc/[pid]/cgroup to their
+// cgroup path. The unified (v2) hierarchy is stored under the empty key.
+type cgroupPaths map[string]string
+
+func readCgroupPaths(root Root, pid string) (cgroupPaths, error) {
+	lines, err := root.ReadLines(root.ProcPath(pid, "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(cgroupPaths)
+	for _, line := range lines {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			ret[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			ret[controller] = fields[2]
+		}
+	}
+	return ret, nil
+}
+
+// cgroupDir returns the directory of the given v1 controller, or of the unified
+// hierarchy if controller is empty, for the paths read from /proc/[pid]/cgroup.
+// Inside a container without a cgroup namespace the host path isn't visible
+// and the mount root is used instead. That root is our own cgroup, so the
+// fallback only applies when pid is "self".
+func cgroupDir(root Root, pid string, paths cgroupPaths, controller string) (string, bool) {
+	cgroupPath, ok := paths[controller]
+	if !ok {
+		return "", false
+	}
+	// a cgroup outside of our cgroup namespace is shown as "/../..." and
+	// must not be resolved to a directory outside of the mount
+	rel := path.Clean(strings.TrimPrefix(cgroupPath, "/"))
+	escapes := rel == ".." || strings.HasPrefix(rel, "../")
+
+	var mounts []string
+	if controller == "" {
+		mounts = []string{root.SysPath("fs/cgroup/unified"), root.SysPath("fs/cgroup")}
+	} else {
+		mounts, _ = root.Glob(root.SysPath("fs/cgroup", "*"+controller+"*"))
+	}
+	for _, mount := range mounts {
+		if controller == "" && !root.Exists(path.Join(mount, "cgroup.controllers")) {
+			continue
+		}
+		if controller != "" && !cgroupMountHas(mount, controller) {
+			continue
+		}
+		var dirs []string
+		if !escapes {
+			dirs = append(dirs, path.Join(mount, rel))
+		}
+		if pid == "self" {
+			dirs = append(dirs, mount)
+		}
+		for _, dir := range dirs {
+			if root.Exists(dir) {
+				return dir, true
+			}
+		}
+	}
+	return "", false
+}
+
+// cgroupMountHas reports whether a v1 mount such as "cpu,cpuacct" carries
+// the controller, so that "cpu" doesn't match "cpuset".
+func cgroupMountHas(mount, controller string) bool {
+	for _, name := range strings.Split(path.Base(mount), ",") {
+		if name == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// CgroupPath returns the directory of the current cgroup for the given v1
+// controller, or of the unified hierarchy if controller is empty. The path is
+// relative to the FS of the collector's Root.
+func (c *Collector) CgroupPath(controller string) (string, error) {
+	return c.cgroupPath("self", controller)
+}
+
+// ProcessCgroupPath is CgroupPath for the cgroup of the process pid.
+func (c *Collector) ProcessCgroupPath(pid int32, controller string) (string, error) {
+	return c.cgroupPath(strconv.Itoa(int(pid)), controller)
+}
+
+func (c *Collector) cgroupPath(pid string, controller string) (string, error) {
+	root := c.Root()
+	paths, err := readCgroupPaths(root, pid)
+	if err != nil {
+		return "", err
+	}
+	dir, ok := cgroupDir(root, pid, paths, controller)
+	if !ok {
+		return "", ErrorNoCgroup
+	}
+	return dir, nil
+}
+
+func (c *Collector) CgroupWithContext(ctx context.Context) (*CgroupStat, error) {
+	return c.cgroupStat("self")
+}
+
+// cgroupStat reads the CPU controller of the cgroup of pid, which is "self"
+// or a number.
+func (c *Collector) cgroupStat(pid string) (*CgroupStat, error) {
+	root := c.Root()
+	paths, err := readCgroupPaths(root, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if usageDir, ok := cgroupDir(root, pid, paths, "cpuacct"); ok {
+		ret := &CgroupStat{Version: 1, Path: paths["cpuacct"]}
+		usage, err := readUint(root, path.Join(usageDir, "cpuacct.usage"))
+		if err != nil {
+			return nil, err
+		}
+		ret.Usage = float64(usage) / 1e9 // nanoseconds
+
+		if cpuDir, ok := cgroupDir(root, pid, paths, "cpu"); ok {
+			quota, err := readInt(root, path.Join(cpuDir, "cpu.cfs_quota_us"))
+			if err == nil && quota > 0 {
+				period, err := readInt(root, path.Join(cpuDir, "cpu.cfs_period_us"))
+				if err == nil && period > 0 {
+					ret.Quota = float64(quota) / float64(period)
+				}
+			}
+		}
+		return ret, nil
+	}
+
+	dir, ok := cgroupDir(root, pid, paths, "")
+	if !ok {
+		return nil, ErrorNoCgroup
+	}
+	ret := &CgroupStat{Version: 2, Path: paths[""]}
+
+	lines, err := root.ReadLines(path.Join(dir, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usage, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			ret.Usage = float64(usage) / 1e6 // microseconds
+		}
+	}
+
+	// "$MAX $PERIOD", where $MAX is "max" if there is no limit
+	lines, err = root.ReadLines(path.Join(dir, "cpu.max"))
+	if err == nil && len(lines) > 0 {
+		fields := strings.Fields(lines[0])
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				ret.Quota = quota / period
+			}
+		}
+	}
+	return ret, nil
+}
+
+func readUint(root Root, name string) (uint64, error) {
+	content, err := root.ReadString(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(content, 10, 64)
+}
+
+func readInt(root Root, name string) (int64, error) {
+	content, err := root.ReadString(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(content, 10, 64)
+}
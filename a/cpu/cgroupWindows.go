@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) CgroupWithContext(ctx context.Context) (*CgroupStat, error) {
+	return nil, ErrorNotImplemented
+}
+
+func (c *Collector) CgroupPath(controller string) (string, error) {
+	return "", ErrorNotImplemented
+}
+
+func (c *Collector) ProcessCgroupPath(pid int32, controller string) (string, error) {
+	return "", ErrorNotImplemented
+}
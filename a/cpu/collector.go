@@ -0,0 +1,173 @@
+package cpu
+
+import (
+	"context"
+	"time"
+)
+
+// Collector samples CPU times and keeps the baseline used by the
+// "since last call" mode of its methods. Collectors are independent of each
+// other, so several callers in the same binary don't disturb their deltas.
+type Collector struct {
+	root   *Root
+	clocks float64
+
+	last lastTimes
+}
+
+type lastTimes struct {
+	cpuTimes             Baseline[[]TicksStat]
+	perCPUTimes          Baseline[[]TicksStat]
+	breakdownTimes       Baseline[[]TicksStat]
+	perCPUBreakdownTimes Baseline[[]TicksStat]
+	perCPUPercentTimes   Baseline[[]TicksStat]
+	cgroup               Baseline[*CgroupStat]
+	systemStat           Baseline[*SystemStat]
+	idleStats            Baseline[[]IdleStat]
+}
+
+type Option func(*Collector)
+
+// WithRoot makes the collector read all files through root instead of
+// DefaultRoot.
+func WithRoot(root Root) Option {
+	return func(c *Collector) {
+		c.root = &root
+	}
+}
+
+// WithClocksPerSec overrides the tick rate used to convert /proc counters,
+// for parsing files captured on a host with a different USER_HZ.
+func WithClocksPerSec(clocks float64) Option {
+	return func(c *Collector) {
+		c.clocks = clocks
+	}
+}
+
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var defaultCollector = NewCollector()
+
+// Root returns the filesystem root the collector reads from. Without
+// WithRoot it is DefaultRoot, evaluated on every call.
+func (c *Collector) Root() Root {
+	if c.root == nil {
+		return DefaultRoot()
+	}
+	return *c.root
+}
+
+func (c *Collector) CountsWithContext(ctx context.Context, logical bool) (int, error) {
+	return c.countsWithContext(ctx, logical)
+}
+
+// PercentWithContext uses the time left until the deadline of ctx as the
+// sampling interval, see PercentInterval.
+func (c *Collector) PercentWithContext(ctx context.Context, percpu bool) ([]float64, error) {
+	return c.PercentInterval(ctx, GetTimeoutDuration(ctx), percpu)
+}
+
+// PercentInterval samples the CPU times twice, interval apart, and returns
+// the busy percentage in between. ctx is only used for cancellation. If
+// interval is 0 or less the values are relative to the previous call.
+// The per-CPU values are indexed by CPU, so if a CPU went offline or online
+// between the samples ErrorCPUsChanged is returned; PerCPUPercentInterval
+// labels every value and copes with that. If counters went backwards the
+// error is a *CounterResetError; with percpu the values are still returned
+// and those of the reset CPUs are 0, see PerCPUPercentInterval to skip them.
+func (c *Collector) PercentInterval(ctx context.Context, interval time.Duration, percpu bool) ([]float64, error) {
+	slot := &c.last.cpuTimes
+	if percpu {
+		slot = &c.last.perCPUTimes
+	}
+	cpuTimes1, cpuTimes2, err := c.sampleInterval(ctx, interval, percpu, slot)
+	if err != nil {
+		return nil, err
+	}
+	pairs := matchCPUs(cpuTimes1, cpuTimes2)
+	if len(pairs.appeared) > 0 || len(pairs.disappeared) > 0 {
+		return nil, ErrorCPUsChanged
+	}
+	ret, _, err := calculateAllBusy(pairs)
+	if err != nil && !percpu {
+		return nil, err
+	}
+	return ret, err
+}
+
+func (c *Collector) PerCPUPercentWithContext(ctx context.Context) (*PerCPUPercentStat, error) {
+	return c.PerCPUPercentInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// PerCPUPercentInterval is PercentInterval with percpu set, but every value
+// is labeled with its CPU and CPUs that were hotplugged between the samples
+// are reported.
+func (c *Collector) PerCPUPercentInterval(ctx context.Context, interval time.Duration) (*PerCPUPercentStat, error) {
+	cpuTimes1, cpuTimes2, err := c.sampleInterval(ctx, interval, true, &c.last.perCPUPercentTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := matchCPUs(cpuTimes1, cpuTimes2)
+	busy, resets, _ := calculateAllBusy(pairs)
+	ret := &PerCPUPercentStat{
+		CPUs:        make([]PercentStat, 0, len(pairs.second)),
+		Appeared:    pairs.appeared,
+		Disappeared: pairs.disappeared,
+		Reset:       resets,
+	}
+	reset := make(map[string]bool, len(resets))
+	for _, cpu := range resets {
+		reset[cpu] = true
+	}
+	for i, t := range pairs.second {
+		if reset[t.CPU] {
+			continue
+		}
+		ret.CPUs = append(ret.CPUs, PercentStat{
+			CPU:     t.CPU,
+			Percent: busy[i],
+		})
+	}
+	return ret, nil
+}
+
+func (c *Collector) BreakdownWithContext(ctx context.Context, percpu bool) ([]BreakdownStat, error) {
+	return c.BreakdownInterval(ctx, GetTimeoutDuration(ctx), percpu)
+}
+
+// BreakdownInterval samples like PercentInterval does. If a CPU went offline
+// or online between the samples ErrorCPUsChanged is returned.
+func (c *Collector) BreakdownInterval(ctx context.Context, interval time.Duration, percpu bool) ([]BreakdownStat, error) {
+	slot := &c.last.breakdownTimes
+	if percpu {
+		slot = &c.last.perCPUBreakdownTimes
+	}
+	cpuTimes1, cpuTimes2, err := c.sampleInterval(ctx, interval, percpu, slot)
+	if err != nil {
+		return nil, err
+	}
+	pairs := matchCPUs(cpuTimes1, cpuTimes2)
+	if len(pairs.appeared) > 0 || len(pairs.disappeared) > 0 {
+		return nil, ErrorCPUsChanged
+	}
+	return calculateAllBreakdown(pairs), nil
+}
+
+// sampleInterval reads the CPU ticks twice, interval apart, see
+// SampleInterval.
+func (c *Collector) sampleInterval(ctx context.Context, interval time.Duration, percpu bool, slot *Baseline[[]TicksStat]) ([]TicksStat, []TicksStat, error) {
+	t1, t2, err := SampleInterval(ctx, interval, slot, func() ([]TicksStat, error) {
+		return c.TicksWithContext(ctx, percpu)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return t1.Value, t2.Value, nil
+}
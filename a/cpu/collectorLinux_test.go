@@ -0,0 +1,149 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCollectorPercentFixture(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/stat": {Data: []byte("cpu  200 0 200 1000 50 0 0 0 0 0\n" +
+			"cpu0 100 0 100 500 30 0 0 0 0 0\n" +
+			"cpu1 100 0 100 500 20 0 0 0 0 0\n" +
+			"intr 1 2 3\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)))
+	ctx := context.Background()
+
+	if _, err := c.PercentInterval(ctx, 0, true); err != ErrorNoBaseline {
+		t.Fatalf("the first call without interval has no baseline, got %v", err)
+	}
+	// cpu0 spends all ticks in user, its iowait drops by one tick and idle
+	// by a few, which must not count as a reset
+	fsys["proc/stat"] = &fstest.MapFile{Data: []byte("cpu  300 0 300 1100 49 0 0 0 0 0\n" +
+		"cpu0 200 0 100 497 29 0 0 0 0 0\n" +
+		"cpu1 100 0 200 600 20 0 0 0 0 0\n")}
+	percent, err := c.PercentInterval(ctx, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(percent) != 2 || percent[0] != 100 || percent[1] != 50 {
+		t.Errorf("PercentInterval = %v, want [100 50]", percent)
+	}
+}
+
+func TestCollectorPercentReset(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/stat": {Data: []byte("cpu  200 0 200 1000 0 0 0 0 0 0\n" +
+			"cpu0 100 0 100 500 0 0 0 0 0 0\n" +
+			"cpu1 100 0 100 500 0 0 0 0 0 0\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)))
+	ctx := context.Background()
+	if _, err := c.PercentInterval(ctx, 0, true); err != ErrorNoBaseline {
+		t.Fatalf("the first call without interval has no baseline, got %v", err)
+	}
+
+	// the user counter of cpu0 goes backwards
+	fsys["proc/stat"] = &fstest.MapFile{Data: []byte("cpu  250 0 300 1100 0 0 0 0 0 0\n" +
+		"cpu0 50 0 100 500 0 0 0 0 0 0\n" +
+		"cpu1 200 0 100 600 0 0 0 0 0 0\n")}
+	percent, err := c.PercentInterval(ctx, 0, true)
+	var reset *CounterResetError
+	if !errors.As(err, &reset) || reset.CPU != "cpu0" || reset.Field != "user" {
+		t.Fatalf("PercentInterval error = %v, want a reset of cpu0 user", err)
+	}
+	if len(percent) != 2 || percent[0] != 0 || percent[1] != 50 {
+		t.Errorf("PercentInterval = %v, want [0 50]", percent)
+	}
+}
+
+func TestCollectorCPUsChanged(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/stat": {Data: []byte("cpu  200 0 200 1000 0 0 0 0 0 0\n" +
+			"cpu0 100 0 100 500 0 0 0 0 0 0\n" +
+			"cpu1 100 0 100 500 0 0 0 0 0 0\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)))
+	ctx := context.Background()
+	c.PercentInterval(ctx, 0, true)
+	c.BreakdownInterval(ctx, 0, true)
+
+	// cpu1 went offline
+	fsys["proc/stat"] = &fstest.MapFile{Data: []byte("cpu  300 0 300 1100 0 0 0 0 0 0\n" +
+		"cpu0 200 0 100 600 0 0 0 0 0 0\n")}
+	if percent, err := c.PercentInterval(ctx, 0, true); err != ErrorCPUsChanged {
+		t.Errorf("PercentInterval = %v, %v, want ErrorCPUsChanged", percent, err)
+	}
+	if breakdown, err := c.BreakdownInterval(ctx, 0, true); err != ErrorCPUsChanged {
+		t.Errorf("BreakdownInterval = %v, %v, want ErrorCPUsChanged", breakdown, err)
+	}
+}
+
+func TestTicksMissingStat(t *testing.T) {
+	c := NewCollector(WithRoot(NewRoot(fstest.MapFS{})))
+	for _, percpu := range []bool{false, true} {
+		if ticks, err := c.TicksWithContext(context.Background(), percpu); err == nil {
+			t.Errorf("TicksWithContext(%v) = %v, want an error", percpu, ticks)
+		}
+	}
+}
+
+func TestProcessCgroupPathNamespaced(t *testing.T) {
+	// inside a cgroup namespace our own path reads as "/", another process
+	// elsewhere in the hierarchy isn't visible under the mount
+	fsys := fstest.MapFS{
+		"proc/self/cgroup":                 {Data: []byte("0::/\n")},
+		"proc/42/cgroup":                   {Data: []byte("0::/../other\n")},
+		"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)))
+
+	if dir, err := c.CgroupPath(""); err != nil || dir != "sys/fs/cgroup" {
+		t.Errorf("CgroupPath = %q, %v, want sys/fs/cgroup", dir, err)
+	}
+	if dir, err := c.ProcessCgroupPath(42, ""); err != ErrorNoCgroup {
+		t.Errorf("ProcessCgroupPath = %q, %v, want ErrorNoCgroup", dir, err)
+	}
+
+	// a path escaping the namespace must not leave the mount, even if the
+	// directory it names exists
+	fsys["proc/43/cgroup"] = &fstest.MapFile{Data: []byte("0::/../../../proc\n")}
+	if dir, err := c.ProcessCgroupPath(43, ""); err != ErrorNoCgroup {
+		t.Errorf("ProcessCgroupPath = %q, %v, want ErrorNoCgroup", dir, err)
+	}
+	fsys["proc/self/cgroup"] = &fstest.MapFile{Data: []byte("0::/../../../proc\n")}
+	if dir, err := c.CgroupPath(""); err != nil || dir != "sys/fs/cgroup" {
+		t.Errorf("CgroupPath = %q, %v, want sys/fs/cgroup", dir, err)
+	}
+}
+
+func TestFreqWithoutCPUInfo(t *testing.T) {
+	// cpufreq is available, /proc/cpuinfo must not be needed
+	fsys := fstest.MapFS{
+		"sys/devices/system/cpu/cpu0/cpufreq/scaling_cur_freq": {Data: []byte("2400000\n")},
+		"sys/devices/system/cpu/cpu0/cpufreq/cpuinfo_min_freq": {Data: []byte("800000\n")},
+		"sys/devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq": {Data: []byte("3600000\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)))
+	freqs, err := c.FreqWithContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FreqStat{CPU: 0, Current: 2400, Min: 800, Max: 3600}
+	if len(freqs) != 1 || freqs[0] != want {
+		t.Errorf("FreqWithContext = %+v, want [%+v]", freqs, want)
+	}
+
+	// without cpufreq the cpuinfo fallback is required
+	fsys = fstest.MapFS{"sys/devices/system/cpu/cpu0/online": {Data: []byte("1\n")}}
+	c = NewCollector(WithRoot(NewRoot(fsys)))
+	if freqs, err := c.FreqWithContext(context.Background()); err == nil {
+		t.Errorf("FreqWithContext = %+v, want an error", freqs)
+	}
+}
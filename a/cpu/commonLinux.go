@@ -4,67 +4,41 @@
 package cpu
 
 import (
-	"bufio"
-	"os"
 	"path/filepath"
 	"strings"
 )
 
+// HostProc returns the host path of the proc tree of DefaultRoot joined with
+// combineWith.
+//
+// Deprecated: use Root.ProcPath.
 func HostProc(combineWith ...string) string {
-	return getEnv("HOST_PROC", "/proc", combineWith...)
+	return "/" + DefaultRoot().ProcPath(combineWith...)
 }
 
-func hostSys(combineWith ...string) string {
-	return getEnv("HOST_SYS", "/sys", combineWith...)
-}
-
-func getEnv(key string, dfault string, combineWith ...string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		value = dfault
-	}
-
-	switch len(combineWith) {
-	case 0:
-		return value
-	case 1:
-		return filepath.Join(value, combineWith[0])
-	default:
-		all := make([]string, len(combineWith)+1)
-		all[0] = value
-		copy(all[1:], combineWith)
-		return filepath.Join(all...)
-	}
+// HostEtc returns the host path of the etc tree of DefaultRoot joined with
+// combineWith.
+//
+// Deprecated: use Root.EtcPath.
+func HostEtc(combineWith ...string) string {
+	return "/" + DefaultRoot().EtcPath(combineWith...)
 }
 
+// ReadLines reads the host file filename through DefaultRoot.
+//
+// Deprecated: use Root.ReadLines.
 func ReadLines(filename string) ([]string, error) {
-	return readLinesOffsetN(filename, 0, -1)
+	return DefaultRoot().ReadLines(hostRelative(filename))
 }
 
-func readLinesOffsetN(filename string, offset uint, n int) ([]string, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return []string{""}, err
+// hostRelative turns a host path into one relative to the FS of DefaultRoot.
+func hostRelative(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
 	}
-	defer f.Close()
-
-	var ret []string
-
-	r := bufio.NewReader(f)
-	for i := 0; i < n+int(offset) || n < 0; i++ {
-		line, err := r.ReadString('\n')
-		if err != nil {
-			break
-		}
-		if i < int(offset) {
-			continue
-		}
-		ret = append(ret, strings.Trim(line, "\n"))
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	if name == "" {
+		return "."
 	}
-
-	return ret, nil
-}
-
-func HostEtc(combineWith ...string) string {
-	return getEnv("HOST_ETC", "/etc", combineWith...)
+	return name
 }
@@ -11,7 +11,10 @@ import (
 
 var (
 	modkernel32        = windows.NewLazySystemDLL("kernel32.dll")
+	modntdll           = windows.NewLazySystemDLL("ntdll.dll")
 	procGetSystemTimes = modkernel32.NewProc("GetSystemTimes")
+
+	procNtQuerySystemInformation = modntdll.NewProc("NtQuerySystemInformation")
 )
 
 type FILETIME struct {
@@ -2,14 +2,15 @@ package cpu
 
 import (
 	"context"
-	"fmt"
-	"math"
-	"sync"
+	"errors"
 	"time"
 )
 
 var (
 	timeout = 3 * time.Second
+
+	ErrorNotImplemented = errors.New("not implemented on this platform")
+	ErrorCPUsChanged    = errors.New("the set of CPUs changed between samples")
 )
 
 type TimesStat struct {
@@ -26,6 +27,23 @@ type TimesStat struct {
 	GuestNice float64 `json:"guestNice"`
 }
 
+// PercentStat is the busy percentage of one CPU.
+type PercentStat struct {
+	CPU     string  `json:"cpu"`
+	Percent float64 `json:"percent"`
+}
+
+// PerCPUPercentStat holds the usage of the CPUs online in both samples.
+// Appeared lists CPUs only in the second sample, Disappeared CPUs only in
+// the first one and Reset CPUs whose counters went backwards in between,
+// which are left out of CPUs.
+type PerCPUPercentStat struct {
+	CPUs        []PercentStat `json:"cpus"`
+	Appeared    []string      `json:"appeared"`
+	Disappeared []string      `json:"disappeared"`
+	Reset       []string      `json:"reset"`
+}
+
 type InfoStat struct {
 	CPU        int32    `json:"cpu"`
 	VendorID   string   `json:"vendorId"`
@@ -42,91 +60,96 @@ type InfoStat struct {
 	Microcode  string   `json:"microcode"`
 }
 
-type lastPercent struct {
-	sync.Mutex
-	lastCPUTimes    []TimesStat
-	lastPerCPUTimes []TimesStat
+func Counts(logical bool) (int, error) {
+	return CountsWithContext(context.Background(), logical)
 }
 
-var lastCPUPercent lastPercent
-
-func Counts(logical bool) (int, error) {
-	return countsWithContext(context.Background(), logical)
+func CountsWithContext(ctx context.Context, logical bool) (int, error) {
+	return defaultCollector.countsWithContext(ctx, logical)
 }
 
-func getAllBusy(t TimesStat) (float64, float64) {
-	busy := t.User + t.System + t.Nice + t.Iowait + t.Irq +
-		t.Softirq + t.Steal
-	return busy + t.Idle, busy
+// Info returns one InfoStat per logical CPU.
+func Info() ([]InfoStat, error) {
+	return InfoWithContext(context.Background())
 }
 
-func calculateBusy(t1, t2 TimesStat) float64 {
-	t1All, t1Busy := getAllBusy(t1)
-	t2All, t2Busy := getAllBusy(t2)
+func InfoWithContext(ctx context.Context) ([]InfoStat, error) {
+	return defaultCollector.InfoWithContext(ctx)
+}
 
-	if t2Busy <= t1Busy {
-		return 0
-	}
-	if t2All <= t1All {
-		return 100
-	}
-	return math.Min(100, math.Max(0, (t2Busy-t1Busy)/(t2All-t1All)*100))
+// cpuPairs holds two samples of CPU ticks matched by CPU name. first and
+// second are parallel and in the order of the second sample.
+type cpuPairs struct {
+	first       []TicksStat
+	second      []TicksStat
+	appeared    []string
+	disappeared []string
 }
 
-func calculateAllBusy(t1, t2 []TimesStat) ([]float64, error) {
-	if len(t1) != len(t2) {
-		return nil, fmt.Errorf(
-			"received two CPU counts: %d != %d",
-			len(t1), len(t2),
-		)
+func matchCPUs(t1, t2 []TicksStat) cpuPairs {
+	var ret cpuPairs
+	byName := make(map[string]TicksStat, len(t1))
+	for _, t := range t1 {
+		byName[t.CPU] = t
 	}
-
-	ret := make([]float64, len(t1))
-	for i, t := range t2 {
-		ret[i] = calculateBusy(t1[i], t)
+	for _, t := range t2 {
+		prev, ok := byName[t.CPU]
+		if !ok {
+			ret.appeared = append(ret.appeared, t.CPU)
+			continue
+		}
+		delete(byName, t.CPU)
+		ret.first = append(ret.first, prev)
+		ret.second = append(ret.second, t)
 	}
-	return ret, nil
-}
-
-func PercentWithContext(ctx context.Context) ([]float64, error) {
-	interval := GetTimeoutDuration(ctx)
-
-	if interval <= 0 {
-		return percentUsedFromLastCall()
+	for _, t := range t1 {
+		if _, ok := byName[t.CPU]; ok {
+			ret.disappeared = append(ret.disappeared, t.CPU)
+		}
 	}
+	return ret
+}
 
-	cpuTimes1, err := times()
-	if err != nil {
-		return nil, err
+// calculateAllBusy returns the busy percentage of every matched CPU. A CPU
+// whose counters went backwards reads 0 and is listed in resets, along with
+// the first such error.
+func calculateAllBusy(pairs cpuPairs) ([]float64, []string, error) {
+	ret := make([]float64, len(pairs.second))
+	var resets []string
+	var resetErr error
+	for i := range pairs.second {
+		busy, err := CalculateTicksBusy(pairs.first[i], pairs.second[i])
+		if err != nil {
+			resets = append(resets, pairs.second[i].CPU)
+			if resetErr == nil {
+				resetErr = err
+			}
+			continue
+		}
+		ret[i] = busy
 	}
+	return ret, resets, resetErr
+}
 
-	if err := Sleep(ctx, interval); err != nil {
-		return nil, err
-	}
+// PercentWithContext calculates the percentage of cpu used either per CPU or
+// combined. If percpu is true, one value is returned for every logical CPU.
+// Without a deadline on ctx the values are relative to the previous call.
+func PercentWithContext(ctx context.Context, percpu bool) ([]float64, error) {
+	return defaultCollector.PercentWithContext(ctx, percpu)
+}
 
-	cpuTimes2, err := times()
-	if err != nil {
-		return nil, err
-	}
+func PerCPUPercentWithContext(ctx context.Context) (*PerCPUPercentStat, error) {
+	return defaultCollector.PerCPUPercentWithContext(ctx)
+}
 
-	return calculateAllBusy(cpuTimes1, cpuTimes2)
+func PerCPUPercentInterval(ctx context.Context, interval time.Duration) (*PerCPUPercentStat, error) {
+	return defaultCollector.PerCPUPercentInterval(ctx, interval)
 }
 
-func percentUsedFromLastCall() ([]float64, error) {
-	cpuTimes, err := times()
-	if err != nil {
-		return nil, err
-	}
-	lastCPUPercent.Lock()
-	defer lastCPUPercent.Unlock()
-	var lastTimes []TimesStat
-	lastTimes = lastCPUPercent.lastCPUTimes
-	lastCPUPercent.lastCPUTimes = cpuTimes
-
-	if lastTimes == nil {
-		return nil, fmt.Errorf("error getting times for cpu percent. lastTimes was nil")
-	}
-	return calculateAllBusy(lastTimes, cpuTimes)
+// PercentInterval is like PercentWithContext, but the sampling interval is
+// given explicitly and ctx is only used for cancellation.
+func PercentInterval(ctx context.Context, interval time.Duration, percpu bool) ([]float64, error) {
+	return defaultCollector.PercentInterval(ctx, interval, percpu)
 }
 
 func GetTimeoutDuration(ctx context.Context) time.Duration {
@@ -145,6 +168,7 @@ func (c TimesStat) Total() float64 {
 
 func Sleep(ctx context.Context, interval time.Duration) error {
 	var timer = time.NewTimer(interval)
+	defer timer.Stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -5,8 +5,8 @@ package cpu
 
 import (
 	"context"
+	"fmt"
 	"github.com/yusufpapurcu/wmi"
-	"golang.org/x/sys/windows"
 	"strings"
 	"unsafe"
 )
@@ -16,6 +16,22 @@ var (
 	procGetNativeSystemInfo     = modkernel32.NewProc("GetNativeSystemInfo")
 )
 
+// SYSTEM_PROCESSOR_PERFORMANCE_INFORMATION, times are in 100ns units
+type win32_SystemProcessorPerformanceInformation struct {
+	IdleTime       int64
+	KernelTime     int64
+	UserTime       int64
+	DpcTime        int64
+	InterruptTime  int64
+	InterruptCount uint32
+}
+
+const (
+	win32_SystemProcessorPerformanceInformationClass    = 8
+	win32_SystemProcessorPerformanceInformationSize     = uint32(unsafe.Sizeof(win32_SystemProcessorPerformanceInformation{}))
+	win32_SystemProcessorPerformanceInformationMaxCount = 2048
+)
+
 type systemInfo struct {
 	wProcessorArchitecture      uint16
 	wReserved                   uint16
@@ -41,40 +57,53 @@ type Win32_ProcessorWithoutLoadPct struct {
 	MaxClockSpeed             uint32
 }
 
-func times() ([]TimesStat, error) {
-	return timesWithContext()
-}
+func (c *Collector) InfoWithContext(ctx context.Context) ([]InfoStat, error) {
+	var dst []Win32_ProcessorWithoutLoadPct
+	q := wmi.CreateQuery(&dst, "")
+	q = strings.ReplaceAll(q, "Win32_ProcessorWithoutLoadPct", "Win32_Processor")
+	if err := wmiQueryWithContext(ctx, q, &dst); err != nil {
+		return nil, err
+	}
 
-func timesWithContext() ([]TimesStat, error) {
-	var ret []TimesStat
-	var lpIdleTime FILETIME
-	var lpKernelTime FILETIME
-	var lpUserTime FILETIME
-	r, _, _ := procGetSystemTimes.Call(
-		uintptr(unsafe.Pointer(&lpIdleTime)),
-		uintptr(unsafe.Pointer(&lpKernelTime)),
-		uintptr(unsafe.Pointer(&lpUserTime)))
-	if r == 0 {
-		return ret, windows.GetLastError()
+	ret := make([]InfoStat, 0, len(dst))
+	for i, l := range dst {
+		procID := ""
+		if l.ProcessorID != nil {
+			procID = *l.ProcessorID
+		}
+		ret = append(ret, InfoStat{
+			CPU:        int32(i),
+			Family:     fmt.Sprintf("%d", l.Family),
+			VendorID:   l.Manufacturer,
+			ModelName:  l.Name,
+			Cores:      int32(l.NumberOfLogicalProcessors),
+			PhysicalID: procID,
+			Mhz:        float64(l.MaxClockSpeed),
+			Flags:      []string{},
+		})
 	}
+	return ret, nil
+}
 
-	LOT := float64(0.0000001)
-	HIT := (LOT * 4294967296.0)
-	idle := ((HIT * float64(lpIdleTime.DwHighDateTime)) + (LOT * float64(lpIdleTime.DwLowDateTime)))
-	user := ((HIT * float64(lpUserTime.DwHighDateTime)) + (LOT * float64(lpUserTime.DwLowDateTime)))
-	kernel := ((HIT * float64(lpKernelTime.DwHighDateTime)) + (LOT * float64(lpKernelTime.DwLowDateTime)))
-	system := (kernel - idle)
+func perfInfo() ([]win32_SystemProcessorPerformanceInformation, error) {
+	buffer := make([]win32_SystemProcessorPerformanceInformation, win32_SystemProcessorPerformanceInformationMaxCount)
+	bufferSize := uintptr(win32_SystemProcessorPerformanceInformationSize) * uintptr(len(buffer))
+	var retSize uint32
 
-	ret = append(ret, TimesStat{
-		CPU:    "cpu-total",
-		Idle:   float64(idle),
-		User:   float64(user),
-		System: float64(system),
-	})
-	return ret, nil
+	retCode, _, err := procNtQuerySystemInformation.Call(
+		win32_SystemProcessorPerformanceInformationClass,
+		uintptr(unsafe.Pointer(&buffer[0])),
+		bufferSize,
+		uintptr(unsafe.Pointer(&retSize)),
+	)
+	if retCode != 0 {
+		return nil, fmt.Errorf("call to NtQuerySystemInformation returned %d. err: %s", retCode, err.Error())
+	}
+
+	return buffer[:retSize/win32_SystemProcessorPerformanceInformationSize], nil
 }
 
-func countsWithContext(ctx context.Context, logical bool) (int, error) {
+func (c *Collector) countsWithContext(ctx context.Context, logical bool) (int, error) {
 	if logical {
 		err := procGetActiveProcessorCount.Find()
 		if err == nil {
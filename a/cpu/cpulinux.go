@@ -6,33 +6,84 @@ package cpu
 import (
 	"context"
 	"errors"
-	"path/filepath"
+	"fmt"
+	"github.com/tklauser/go-sysconf"
 	"strconv"
 	"strings"
 )
 
+// ClocksPerSec is USER_HZ, the unit of the tick counters in /proc. It is
+// queried once at startup and is shared with the process package.
 var ClocksPerSec = float64(100)
 
-func timesWithContext(ctx context.Context) ([]TimesStat, error) {
-	filename := HostProc("stat")
-	var lines = []string{}
+func init() {
+	clkTck, err := sysconf.Sysconf(sysconf.SC_CLK_TCK)
+	// ignore errors, keep the kernel default
+	if err == nil && clkTck > 0 {
+		ClocksPerSec = float64(clkTck)
+	}
+}
 
-	lines, _ = readLinesOffsetN(filename, 0, 1)
+func (c *Collector) clocksPerSec() float64 {
+	if c.clocks > 0 {
+		return c.clocks
+	}
+	return ClocksPerSec
+}
 
-	ret := make([]TimesStat, 0, len(lines))
+// TicksWithContext returns the raw counters of /proc/stat, either of every
+// logical CPU or of the aggregate line.
+func (c *Collector) TicksWithContext(ctx context.Context, percpu bool) ([]TicksStat, error) {
+	root := c.Root()
+	filename := root.ProcPath("stat")
+	var lines []string
 
+	if percpu {
+		statlines, err := root.ReadLines(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range statlines {
+			// per-cpu lines are "cpuN ...", the aggregate line is plain "cpu ..."
+			if len(line) < 4 || !strings.HasPrefix(line, "cpu") || line[3] < '0' || line[3] > '9' {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	} else {
+		var err error
+		if lines, err = root.readLinesOffsetN(filename, 0, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]TicksStat, 0, len(lines))
 	for _, line := range lines {
-		ct, err := parseStatLine(line)
+		ct, err := parseTicksLine(line)
 		if err != nil {
+			if !percpu {
+				return nil, err
+			}
 			continue
 		}
 		ret = append(ret, *ct)
-
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("could not find cpu lines in %s", filename)
 	}
 	return ret, nil
 }
 
-func parseStatLine(line string) (*TimesStat, error) {
+func parseStatLine(line string, clocksPerSec float64) (*TimesStat, error) {
+	ticks, err := parseTicksLine(line)
+	if err != nil {
+		return nil, err
+	}
+	ct := ticks.Times(clocksPerSec)
+	return &ct, nil
+}
+
+func parseTicksLine(line string) (*TicksStat, error) {
 	fields := strings.Fields(line)
 
 	if len(fields) == 0 {
@@ -43,83 +94,155 @@ func parseStatLine(line string) (*TimesStat, error) {
 		return nil, errors.New("not contain cpu")
 	}
 
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("too few fields in stat line %q", line)
+	}
+
 	cpu := fields[0]
 	if cpu == "cpu" {
 		cpu = "cpu-total"
 	}
-	user, err := strconv.ParseFloat(fields[1], 64)
-	if err != nil {
-		return nil, err
+
+	ct := &TicksStat{CPU: cpu}
+	// Linux >= 2.6.11 adds steal, 2.6.24 guest and 3.2.0 guest_nice
+	counters := []*uint64{
+		&ct.User, &ct.Nice, &ct.System, &ct.Idle, &ct.Iowait, &ct.Irq,
+		&ct.Softirq, &ct.Steal, &ct.Guest, &ct.GuestNice,
 	}
-	nice, err := strconv.ParseFloat(fields[2], 64)
-	if err != nil {
-		return nil, err
+	for i, field := range fields[1:] {
+		if i >= len(counters) {
+			break
+		}
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		*counters[i] = v
 	}
-	system, err := strconv.ParseFloat(fields[3], 64)
-	if err != nil {
-		return nil, err
+
+	return ct, nil
+}
+
+func sysCPUPath(root Root, cpu int32, relPath string) string {
+	return root.SysPath(fmt.Sprintf("devices/system/cpu/cpu%d", cpu), relPath)
+}
+
+func finishCPUInfo(root Root, c *InfoStat) {
+	if len(c.CoreID) == 0 {
+		lines, err := root.ReadLines(sysCPUPath(root, c.CPU, "topology/core_id"))
+		if err == nil && len(lines) > 0 {
+			c.CoreID = lines[0]
+		}
 	}
-	idle, err := strconv.ParseFloat(fields[4], 64)
-	if err != nil {
-		return nil, err
+	if len(c.PhysicalID) == 0 {
+		lines, err := root.ReadLines(sysCPUPath(root, c.CPU, "topology/physical_package_id"))
+		if err == nil && len(lines) > 0 {
+			c.PhysicalID = lines[0]
+		}
 	}
-	iowait, err := strconv.ParseFloat(fields[5], 64)
-	if err != nil {
-		return nil, err
+
+	// ARM and some virtualized x86 kernels don't report "cpu MHz",
+	// fall back to the current frequency exported by cpufreq, or its
+	// maximum if the driver doesn't report the current one.
+	if c.Mhz != 0 {
+		return
 	}
-	irq, err := strconv.ParseFloat(fields[6], 64)
-	if err != nil {
-		return nil, err
+	for _, name := range []string{"scaling_cur_freq", "cpuinfo_cur_freq", "cpuinfo_max_freq"} {
+		if mhz, err := readFreqMhz(root, c.CPU, name); err == nil {
+			c.Mhz = mhz
+			return
+		}
 	}
-	softirq, err := strconv.ParseFloat(fields[7], 64)
+}
+
+func (c *Collector) InfoWithContext(ctx context.Context) ([]InfoStat, error) {
+	root := c.Root()
+	lines, err := root.ReadLines(root.ProcPath("cpuinfo"))
 	if err != nil {
 		return nil, err
 	}
 
-	ct := &TimesStat{
-		CPU:     cpu,
-		User:    user / ClocksPerSec,
-		Nice:    nice / ClocksPerSec,
-		System:  system / ClocksPerSec,
-		Idle:    idle / ClocksPerSec,
-		Iowait:  iowait / ClocksPerSec,
-		Irq:     irq / ClocksPerSec,
-		Softirq: softirq / ClocksPerSec,
-	}
-	if len(fields) > 8 { // Linux >= 2.6.11
-		steal, err := strconv.ParseFloat(fields[8], 64)
-		if err != nil {
-			return nil, err
+	var ret []InfoStat
+	var processorName string
+
+	info := InfoStat{CPU: -1, Cores: 1}
+	for _, line := range lines {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) < 2 {
+			continue
 		}
-		ct.Steal = steal / ClocksPerSec
-	}
-	if len(fields) > 9 { // Linux >= 2.6.24
-		guest, err := strconv.ParseFloat(fields[9], 64)
-		if err != nil {
-			return nil, err
+		key := strings.TrimSpace(fields[0])
+		value := strings.TrimSpace(fields[1])
+
+		switch key {
+		case "Processor":
+			// older ARM kernels print the model name once for all processors
+			processorName = value
+		case "processor", "cpu number":
+			if info.CPU >= 0 {
+				finishCPUInfo(root, &info)
+				ret = append(ret, info)
+			}
+			info = InfoStat{Cores: 1, ModelName: processorName}
+			t, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return ret, err
+			}
+			info.CPU = int32(t)
+		case "vendor_id", "vendorId", "CPU implementer":
+			info.VendorID = value
+		case "cpu family", "CPU architecture":
+			info.Family = value
+		case "model", "CPU part":
+			info.Model = value
+		case "model name", "Model Name", "cpu":
+			info.ModelName = value
+		case "stepping", "revision", "CPU revision":
+			val := value
+			if key == "revision" {
+				val = strings.Split(value, ".")[0]
+			}
+			t, err := strconv.ParseInt(val, 0, 32)
+			if err != nil {
+				return ret, err
+			}
+			info.Stepping = int32(t)
+		case "cpu MHz", "clock", "cpu MHz dynamic":
+			// treat this as a hint only, cpufreq is consulted when it is missing
+			if t, err := strconv.ParseFloat(strings.TrimSuffix(value, "MHz"), 64); err == nil {
+				info.Mhz = t
+			}
+		case "cache size":
+			t, err := strconv.ParseInt(strings.TrimSuffix(value, " KB"), 10, 32)
+			if err != nil {
+				return ret, err
+			}
+			info.CacheSize = int32(t)
+		case "physical id":
+			info.PhysicalID = value
+		case "core id":
+			info.CoreID = value
+		case "flags", "Features":
+			info.Flags = strings.FieldsFunc(value, func(r rune) bool {
+				return r == ',' || r == ' '
+			})
+		case "microcode":
+			info.Microcode = value
 		}
-		ct.Guest = guest / ClocksPerSec
 	}
-	if len(fields) > 10 { // Linux >= 3.2.0
-		guestNice, err := strconv.ParseFloat(fields[10], 64)
-		if err != nil {
-			return nil, err
-		}
-		ct.GuestNice = guestNice / ClocksPerSec
+	if info.CPU >= 0 {
+		finishCPUInfo(root, &info)
+		ret = append(ret, info)
 	}
-
-	return ct, nil
-}
-
-func times() ([]TimesStat, error) {
-	return timesWithContext(context.Background())
+	return ret, nil
 }
 
-func countsWithContext(ctx context.Context, logical bool) (int, error) {
+func (c *Collector) countsWithContext(ctx context.Context, logical bool) (int, error) {
+	root := c.Root()
 	if logical {
 		ret := 0
-		procCpuinfo := HostProc("cpuinfo")
-		lines, err := ReadLines(procCpuinfo)
+		procCpuinfo := root.ProcPath("cpuinfo")
+		lines, err := root.ReadLines(procCpuinfo)
 		if err == nil {
 			for _, line := range lines {
 				line = strings.ToLower(line)
@@ -132,8 +255,8 @@ func countsWithContext(ctx context.Context, logical bool) (int, error) {
 			}
 		}
 		if ret == 0 {
-			procStat := HostProc("stat")
-			lines, err = ReadLines(procStat)
+			procStat := root.ProcPath("stat")
+			lines, err = root.ReadLines(procStat)
 			if err != nil {
 				return 0, err
 			}
@@ -147,13 +270,18 @@ func countsWithContext(ctx context.Context, logical bool) (int, error) {
 	}
 	var threadSiblingsLists = make(map[string]bool)
 	for _, glob := range []string{"devices/system/cpu/cpu[0-9]*/topology/core_cpus_list", "devices/system/cpu/cpu[0-9]*/topology/thread_siblings_list"} {
-		if files, err := filepath.Glob(hostSys(glob)); err == nil {
+		if files, err := root.Glob(root.SysPath(glob)); err == nil {
 			for _, file := range files {
-				lines, err := ReadLines(file)
+				lines, err := root.ReadLines(file)
 				if err != nil || len(lines) != 1 {
 					continue
 				}
-				threadSiblingsLists[lines[0]] = true
+				// key by the canonical list so "0,1" and "0-1" are one core
+				siblings, err := ParseSet(lines[0])
+				if err != nil {
+					continue
+				}
+				threadSiblingsLists[siblings.String()] = true
 			}
 			ret := len(threadSiblingsLists)
 			if ret != 0 {
@@ -161,8 +289,8 @@ func countsWithContext(ctx context.Context, logical bool) (int, error) {
 			}
 		}
 	}
-	filename := HostProc("cpuinfo")
-	lines, err := ReadLines(filename)
+	filename := root.ProcPath("cpuinfo")
+	lines, err := root.ReadLines(filename)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,48 @@
+package cpu
+
+import (
+	"context"
+	"math"
+)
+
+// EffectiveCountsStat lists the limits that decide how many CPUs a process
+// can actually use. Counts that are unknown or unlimited
+// are 0.
+type EffectiveCountsStat struct {
+	// Online is the number of logical CPUs online on the host.
+	Online int `json:"online"`
+	// Affinity is the number of CPUs in the affinity mask of the process.
+	Affinity int `json:"affinity"`
+	// Cpuset is the number of CPUs in the effective cgroup cpuset.
+	Cpuset int `json:"cpuset"`
+	// Quota is the cgroup CPU quota in CPUs, it can be fractional.
+	Quota float64 `json:"quota"`
+}
+
+// Effective returns the smallest of the known limits.
+func (e EffectiveCountsStat) Effective() float64 {
+	ret := math.Inf(1)
+	for _, n := range []float64{float64(e.Online), float64(e.Affinity), float64(e.Cpuset), e.Quota} {
+		if n > 0 && n < ret {
+			ret = n
+		}
+	}
+	if math.IsInf(ret, 1) {
+		return 0
+	}
+	return ret
+}
+
+func EffectiveCounts() (*EffectiveCountsStat, error) {
+	return EffectiveCountsWithContext(context.Background())
+}
+
+func EffectiveCountsWithContext(ctx context.Context) (*EffectiveCountsStat, error) {
+	return defaultCollector.EffectiveCountsWithContext(ctx)
+}
+
+// ProcessEffectiveCountsWithContext returns the limits of the process pid
+// instead of the current one.
+func ProcessEffectiveCountsWithContext(ctx context.Context, pid int32) (*EffectiveCountsStat, error) {
+	return defaultCollector.ProcessEffectiveCountsWithContext(ctx, pid)
+}
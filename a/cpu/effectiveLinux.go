@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func (c *Collector) EffectiveCountsWithContext(ctx context.Context) (*EffectiveCountsStat, error) {
+	return c.effectiveCounts(ctx, "self")
+}
+
+func (c *Collector) ProcessEffectiveCountsWithContext(ctx context.Context, pid int32) (*EffectiveCountsStat, error) {
+	return c.effectiveCounts(ctx, strconv.Itoa(int(pid)))
+}
+
+// effectiveCounts reads the limits of pid, which is "self" or a number.
+func (c *Collector) effectiveCounts(ctx context.Context, pid string) (*EffectiveCountsStat, error) {
+	root := c.Root()
+	ret := &EffectiveCountsStat{}
+
+	if lines, err := root.ReadLines(root.SysPath("devices/system/cpu/online")); err == nil && len(lines) > 0 {
+		if cpus, err := ParseSet(lines[0]); err == nil {
+			ret.Online = cpus.Len()
+		}
+	}
+	if ret.Online == 0 {
+		n, err := c.countsWithContext(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		ret.Online = n
+	}
+
+	if lines, err := root.ReadLines(root.ProcPath(pid, "status")); err == nil {
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "Cpus_allowed_list:") {
+				continue
+			}
+			if cpus, err := ParseSet(strings.TrimPrefix(line, "Cpus_allowed_list:")); err == nil {
+				ret.Affinity = cpus.Len()
+			}
+			break
+		}
+	}
+
+	paths, err := readCgroupPaths(root, pid)
+	if err != nil {
+		return ret, nil
+	}
+	var cpusetFiles []string
+	if dir, ok := cgroupDir(root, pid, paths, "cpuset"); ok {
+		cpusetFiles = []string{path.Join(dir, "cpuset.effective_cpus"), path.Join(dir, "cpuset.cpus")}
+	} else if dir, ok := cgroupDir(root, pid, paths, ""); ok {
+		cpusetFiles = []string{path.Join(dir, "cpuset.cpus.effective")}
+	}
+	for _, file := range cpusetFiles {
+		lines, err := root.ReadLines(file)
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		if cpus, err := ParseSet(lines[0]); err == nil && !cpus.IsEmpty() {
+			ret.Cpuset = cpus.Len()
+			break
+		}
+	}
+
+	if cg, err := c.cgroupStat(pid); err == nil {
+		ret.Quota = cg.Quota
+	}
+	return ret, nil
+}
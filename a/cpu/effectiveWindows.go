@@ -0,0 +1,44 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import (
+	"context"
+	"golang.org/x/sys/windows"
+	"math/bits"
+	"unsafe"
+)
+
+var procGetProcessAffinityMask = modkernel32.NewProc("GetProcessAffinityMask")
+
+func (c *Collector) EffectiveCountsWithContext(ctx context.Context) (*EffectiveCountsStat, error) {
+	return c.effectiveCounts(ctx, windows.CurrentProcess())
+}
+
+func (c *Collector) ProcessEffectiveCountsWithContext(ctx context.Context, pid int32) (*EffectiveCountsStat, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(h)
+	return c.effectiveCounts(ctx, h)
+}
+
+func (c *Collector) effectiveCounts(ctx context.Context, process windows.Handle) (*EffectiveCountsStat, error) {
+	n, err := c.countsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	ret := &EffectiveCountsStat{Online: n}
+
+	var processMask, systemMask uintptr
+	r, _, _ := procGetProcessAffinityMask.Call(
+		uintptr(process),
+		uintptr(unsafe.Pointer(&processMask)),
+		uintptr(unsafe.Pointer(&systemMask)))
+	if r != 0 {
+		ret.Affinity = bits.OnesCount64(uint64(processMask))
+	}
+	return ret, nil
+}
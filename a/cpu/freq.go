@@ -0,0 +1,142 @@
+package cpu
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FreqStat is the frequency of a logical CPU in MHz. Min and Max are the
+// hardware limits, they are 0 if cpufreq isn't available.
+type FreqStat struct {
+	CPU     int32   `json:"cpu"`
+	Current float64 `json:"current"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// freqSampleStep is how often the frequency is read while averaging.
+var freqSampleStep = 100 * time.Millisecond
+
+func Freq() ([]FreqStat, error) {
+	return FreqWithContext(context.Background())
+}
+
+func FreqWithContext(ctx context.Context) ([]FreqStat, error) {
+	return defaultCollector.FreqWithContext(ctx)
+}
+
+func FreqAverageWithContext(ctx context.Context) ([]FreqStat, error) {
+	return defaultCollector.FreqAverageWithContext(ctx)
+}
+
+func FreqAverageInterval(ctx context.Context, interval time.Duration) ([]FreqStat, error) {
+	return defaultCollector.FreqAverageInterval(ctx, interval)
+}
+
+func (c *Collector) FreqWithContext(ctx context.Context) ([]FreqStat, error) {
+	return c.freqWithContext(ctx, &cpuinfoMhz{})
+}
+
+// FreqAverageWithContext uses the time left until the deadline of ctx as the
+// sampling interval, see FreqAverageInterval.
+func (c *Collector) FreqAverageWithContext(ctx context.Context) ([]FreqStat, error) {
+	return c.FreqAverageInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// FreqAverageInterval reads the current frequency of every CPU repeatedly
+// for interval and returns the average in Current. ctx is only used for
+// cancellation. If interval is 0 or less a single reading is returned.
+// CPUs without cpufreq report the same /proc/cpuinfo value throughout, it is
+// read only once.
+func (c *Collector) FreqAverageInterval(ctx context.Context, interval time.Duration) ([]FreqStat, error) {
+	cpuinfo := &cpuinfoMhz{}
+	ret, err := c.freqWithContext(ctx, cpuinfo)
+	if err != nil || interval <= 0 {
+		return ret, err
+	}
+
+	// CPUs may go offline in between, so every CPU is averaged over the
+	// readings it was part of
+	samples := make(map[int32]int, len(ret))
+	for _, f := range ret {
+		samples[f.CPU] = 1
+	}
+	deadline := time.Now().Add(interval)
+	for {
+		left := time.Until(deadline)
+		if left <= 0 {
+			break
+		}
+		step := freqSampleStep
+		if left < step {
+			step = left
+		}
+		if err := Sleep(ctx, step); err != nil {
+			return nil, err
+		}
+
+		freqs, err := c.freqWithContext(ctx, cpuinfo)
+		if err != nil {
+			return nil, err
+		}
+		byCPU := make(map[int32]float64, len(freqs))
+		for _, f := range freqs {
+			byCPU[f.CPU] = f.Current
+		}
+		for i := range ret {
+			if mhz, ok := byCPU[ret[i].CPU]; ok {
+				ret[i].Current += mhz
+				samples[ret[i].CPU]++
+			}
+		}
+	}
+
+	for i := range ret {
+		ret[i].Current /= float64(samples[ret[i].CPU])
+	}
+	return ret, nil
+}
+
+func InfoAverageWithContext(ctx context.Context) ([]InfoStat, error) {
+	return defaultCollector.InfoAverageWithContext(ctx)
+}
+
+func InfoAverageInterval(ctx context.Context, interval time.Duration) ([]InfoStat, error) {
+	return defaultCollector.InfoAverageInterval(ctx, interval)
+}
+
+// InfoAverageWithContext uses the time left until the deadline of ctx as the
+// sampling interval, see InfoAverageInterval.
+func (c *Collector) InfoAverageWithContext(ctx context.Context) ([]InfoStat, error) {
+	return c.InfoAverageInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// InfoAverageInterval is InfoWithContext with Mhz set to the average
+// frequency of each CPU over interval, see FreqAverageInterval. Where
+// frequencies aren't available per CPU, Mhz is left as InfoWithContext
+// reports it.
+func (c *Collector) InfoAverageInterval(ctx context.Context, interval time.Duration) ([]InfoStat, error) {
+	ret, err := c.InfoWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	freqs, err := c.FreqAverageInterval(ctx, interval)
+	if errors.Is(err, ErrorNotImplemented) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byCPU := make(map[int32]float64, len(freqs))
+	for _, f := range freqs {
+		byCPU[f.CPU] = f.Current
+	}
+	for i := range ret {
+		if mhz, ok := byCPU[ret[i].CPU]; ok && mhz > 0 {
+			ret[i].Mhz = mhz
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,128 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpuinfoMhz holds the "cpu MHz" fields of /proc/cpuinfo, the fallback when
+// cpufreq is missing, as on most virtual machines. The file is read on first
+// use only: reading it can make the kernel sample every CPU on x86, waking
+// idle cores and changing the frequencies being measured.
+type cpuinfoMhz struct {
+	read   bool
+	values map[int32]float64
+	err    error
+}
+
+func (m *cpuinfoMhz) get(root Root) (map[int32]float64, error) {
+	if !m.read {
+		m.values, m.err = readCPUInfoMhz(root)
+		m.read = true
+	}
+	return m.values, m.err
+}
+
+func (c *Collector) freqWithContext(ctx context.Context, cpuinfo *cpuinfoMhz) ([]FreqStat, error) {
+	root := c.Root()
+
+	cpus := sysCPUNumbers(root)
+	if len(cpus) == 0 {
+		mhz, err := cpuinfo.get(root)
+		if err != nil {
+			return nil, err
+		}
+		for cpu := range mhz {
+			cpus = append(cpus, cpu)
+		}
+		sort.Slice(cpus, func(i, j int) bool { return cpus[i] < cpus[j] })
+	}
+
+	ret := make([]FreqStat, 0, len(cpus))
+	for _, cpu := range cpus {
+		f := FreqStat{CPU: cpu}
+		if mhz, err := readFreqMhz(root, cpu, "scaling_cur_freq"); err == nil {
+			f.Current = mhz
+		} else if mhz, err := readFreqMhz(root, cpu, "cpuinfo_cur_freq"); err == nil {
+			f.Current = mhz
+		} else {
+			mhz, err := cpuinfo.get(root)
+			if err != nil {
+				return nil, err
+			}
+			f.Current = mhz[cpu]
+		}
+		f.Min, _ = readFreqMhz(root, cpu, "cpuinfo_min_freq")
+		f.Max, _ = readFreqMhz(root, cpu, "cpuinfo_max_freq")
+		ret = append(ret, f)
+	}
+	return ret, nil
+}
+
+// readFreqMhz reads a cpufreq file of the given CPU, which is in kHz.
+func readFreqMhz(root Root, cpu int32, name string) (float64, error) {
+	value, err := root.ReadString(sysCPUPath(root, cpu, path.Join("cpufreq", name)))
+	if err != nil {
+		return 0, err
+	}
+	khz, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return khz / 1000.0, nil
+}
+
+// sysCPUNumbers returns the numbers of the cpuN directories in sysfs.
+func sysCPUNumbers(root Root) []int32 {
+	dirs, err := root.Glob(root.SysPath("devices/system/cpu/cpu[0-9]*"))
+	if err != nil {
+		return nil
+	}
+	var ret []int32
+	for _, dir := range dirs {
+		n, err := strconv.ParseInt(strings.TrimPrefix(path.Base(dir), "cpu"), 10, 32)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, int32(n))
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+func readCPUInfoMhz(root Root) (map[int32]float64, error) {
+	lines, err := root.ReadLines(root.ProcPath("cpuinfo"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int32]float64)
+	cpu := int32(-1)
+	for _, line := range lines {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) < 2 {
+			continue
+		}
+		key := strings.TrimSpace(fields[0])
+		value := strings.TrimSpace(fields[1])
+		switch key {
+		case "processor", "cpu number":
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			cpu = int32(n)
+			ret[cpu] = 0
+		case "cpu MHz", "clock", "cpu MHz dynamic":
+			if mhz, err := strconv.ParseFloat(strings.TrimSuffix(value, "MHz"), 64); err == nil && cpu >= 0 {
+				ret[cpu] = mhz
+			}
+		}
+	}
+	return ret, nil
+}
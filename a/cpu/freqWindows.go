@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+type cpuinfoMhz struct{}
+
+func (c *Collector) freqWithContext(ctx context.Context, cpuinfo *cpuinfoMhz) ([]FreqStat, error) {
+	return nil, ErrorNotImplemented
+}
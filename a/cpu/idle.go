@@ -0,0 +1,152 @@
+package cpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrorNoCpuidle = errors.New("cpuidle is not available")
+
+// IdleStateStat is one cpuidle state (C-state) of a logical CPU. Usage and
+// Time are cumulative since boot.
+type IdleStateStat struct {
+	// Name is the kernel's name of the state, such as "POLL", "C1" or "C6".
+	Name string `json:"name"`
+	// Latency is the exit latency in microseconds.
+	Latency uint64 `json:"latency"`
+	// Usage is the number of times the state was entered.
+	Usage uint64 `json:"usage"`
+	// Time is the time spent in the state, in microseconds.
+	Time     uint64 `json:"time"`
+	Disabled bool   `json:"disabled"`
+}
+
+// IdleStat holds the cpuidle states of a logical CPU, shallowest first.
+type IdleStat struct {
+	CPU    int32           `json:"cpu"`
+	States []IdleStateStat `json:"states"`
+	// Timestamp is when the states were read.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IdleStateResidencyStat is the use of a cpuidle state between two samples.
+type IdleStateResidencyStat struct {
+	Name    string `json:"name"`
+	Latency uint64 `json:"latency"`
+	// Usage is the number of times the state was entered.
+	Usage uint64 `json:"usage"`
+	// Time is the time spent in the state, in seconds.
+	Time float64 `json:"time"`
+	// Percent is the share of the CPU's idle time spent in the state.
+	Percent float64 `json:"percent"`
+	// WallPercent is the share of the whole interval spent in the state.
+	WallPercent float64 `json:"wallPercent"`
+}
+
+// IdleResidencyStat is the C-state residency of a logical CPU between two
+// samples.
+type IdleResidencyStat struct {
+	CPU             int32                    `json:"cpu"`
+	States          []IdleStateResidencyStat `json:"states"`
+	IntervalSeconds float64                  `json:"intervalSeconds"`
+}
+
+func Idle() ([]IdleStat, error) {
+	return IdleWithContext(context.Background())
+}
+
+func IdleWithContext(ctx context.Context) ([]IdleStat, error) {
+	return defaultCollector.IdleWithContext(ctx)
+}
+
+func IdleResidencyWithContext(ctx context.Context) ([]IdleResidencyStat, error) {
+	return defaultCollector.IdleResidencyWithContext(ctx)
+}
+
+func IdleResidencyInterval(ctx context.Context, interval time.Duration) ([]IdleResidencyStat, error) {
+	return defaultCollector.IdleResidencyInterval(ctx, interval)
+}
+
+// CalculateIdleResidency returns the residency between s1 and the later
+// sample s2. CPUs are matched by number, one missing from either sample or
+// whose states changed in between is left out.
+func CalculateIdleResidency(s1, s2 []IdleStat) ([]IdleResidencyStat, error) {
+	first := make(map[int32]IdleStat, len(s1))
+	for _, s := range s1 {
+		first[s.CPU] = s
+	}
+
+	ret := make([]IdleResidencyStat, 0, len(s2))
+	for _, cur := range s2 {
+		prev, ok := first[cur.CPU]
+		if !ok || !sameIdleStates(prev, cur) {
+			continue
+		}
+		elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+		if elapsed <= 0 {
+			return nil, fmt.Errorf("samples are not in order: %v, %v", prev.Timestamp, cur.Timestamp)
+		}
+
+		r := IdleResidencyStat{
+			CPU:             cur.CPU,
+			States:          make([]IdleStateResidencyStat, len(cur.States)),
+			IntervalSeconds: elapsed,
+		}
+		var idle uint64
+		for i, s := range cur.States {
+			p := prev.States[i]
+			if s.Usage < p.Usage || s.Time < p.Time {
+				return nil, ErrorCounterDecreased
+			}
+			idle += s.Time - p.Time
+			r.States[i] = IdleStateResidencyStat{
+				Name:    s.Name,
+				Latency: s.Latency,
+				Usage:   s.Usage - p.Usage,
+				Time:    float64(s.Time-p.Time) / 1e6,
+			}
+		}
+		for i := range r.States {
+			if idle > 0 {
+				r.States[i].Percent = r.States[i].Time * 1e6 / float64(idle) * 100
+			}
+			r.States[i].WallPercent = r.States[i].Time / elapsed * 100
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func sameIdleStates(s1, s2 IdleStat) bool {
+	if len(s1.States) != len(s2.States) {
+		return false
+	}
+	for i := range s1.States {
+		if s1.States[i].Name != s2.States[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// IdleResidencyWithContext uses the time left until the deadline of ctx as
+// the sampling interval, see IdleResidencyInterval.
+func (c *Collector) IdleResidencyWithContext(ctx context.Context) ([]IdleResidencyStat, error) {
+	return c.IdleResidencyInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// IdleResidencyInterval reads the cpuidle states twice, interval apart, and
+// returns how each CPU's idle time was split between them. ctx is only used
+// for cancellation. If interval is 0 or less the values are relative to the
+// previous call.
+func (c *Collector) IdleResidencyInterval(ctx context.Context, interval time.Duration) ([]IdleResidencyStat, error) {
+	s1, s2, err := SampleInterval(ctx, interval, &c.last.idleStats, func() ([]IdleStat, error) {
+		return c.IdleWithContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CalculateIdleResidency(s1.Value, s2.Value)
+}
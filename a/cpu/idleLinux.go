@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (c *Collector) IdleWithContext(ctx context.Context) ([]IdleStat, error) {
+	root := c.Root()
+
+	var ret []IdleStat
+	for _, cpu := range sysCPUNumbers(root) {
+		dirs, err := root.Glob(sysCPUPath(root, cpu, "cpuidle/state[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		if len(dirs) == 0 {
+			continue
+		}
+		stateNumber := func(dir string) int {
+			n, _ := strconv.Atoi(strings.TrimPrefix(path.Base(dir), "state"))
+			return n
+		}
+		sort.Slice(dirs, func(i, j int) bool { return stateNumber(dirs[i]) < stateNumber(dirs[j]) })
+
+		s := IdleStat{CPU: cpu, States: make([]IdleStateStat, 0, len(dirs))}
+		for _, dir := range dirs {
+			state, err := readIdleState(root, dir)
+			if err != nil {
+				return nil, err
+			}
+			s.States = append(s.States, state)
+		}
+		s.Timestamp = time.Now()
+		ret = append(ret, s)
+	}
+	if len(ret) == 0 {
+		return nil, ErrorNoCpuidle
+	}
+	return ret, nil
+}
+
+func readIdleState(root Root, dir string) (IdleStateStat, error) {
+	var ret IdleStateStat
+	var err error
+
+	if ret.Name, err = root.ReadString(path.Join(dir, "name")); err != nil {
+		return ret, err
+	}
+	if ret.Latency, err = readUint(root, path.Join(dir, "latency")); err != nil {
+		return ret, err
+	}
+	if ret.Usage, err = readUint(root, path.Join(dir, "usage")); err != nil {
+		return ret, err
+	}
+	if ret.Time, err = readUint(root, path.Join(dir, "time")); err != nil {
+		return ret, err
+	}
+	// "disable" appeared in Linux 3.10
+	if disabled, err := readUint(root, path.Join(dir, "disable")); err == nil {
+		ret.Disabled = disabled != 0
+	}
+	return ret, nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) IdleWithContext(ctx context.Context) ([]IdleStat, error) {
+	return nil, ErrorNotImplemented
+}
@@ -0,0 +1,157 @@
+package cpu
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Root locates the proc, sys and etc trees every reader in this module
+// resolves its paths against. The directories are slash separated and
+// relative to FS, as required by io/fs. A zero FS means the host filesystem.
+type Root struct {
+	FS   fs.FS
+	Proc string
+	Sys  string
+	Etc  string
+}
+
+// DefaultRoot returns the host filesystem with the HOST_PROC, HOST_SYS and
+// HOST_ETC environment variables applied.
+func DefaultRoot() Root {
+	return Root{
+		Proc: envRoot("HOST_PROC", "/proc"),
+		Sys:  envRoot("HOST_SYS", "/sys"),
+		Etc:  envRoot("HOST_ETC", "/etc"),
+	}
+}
+
+// NewRoot returns a Root whose trees are the proc, sys and etc directories
+// at the top of fsys, e.g. os.DirFS("/host") or a fstest.MapFS fixture.
+func NewRoot(fsys fs.FS) Root {
+	return Root{
+		FS:   fsys,
+		Proc: "proc",
+		Sys:  "sys",
+		Etc:  "etc",
+	}
+}
+
+func envRoot(key string, dfault string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = dfault
+	}
+	if abs, err := filepath.Abs(value); err == nil {
+		value = abs
+	}
+	return strings.TrimPrefix(filepath.ToSlash(value), "/")
+}
+
+var hostFS = os.DirFS("/")
+
+func (r Root) fsys() fs.FS {
+	if r.FS == nil {
+		return hostFS
+	}
+	return r.FS
+}
+
+func (r Root) ProcPath(elem ...string) string {
+	return path.Join(append([]string{r.Proc}, elem...)...)
+}
+
+func (r Root) SysPath(elem ...string) string {
+	return path.Join(append([]string{r.Sys}, elem...)...)
+}
+
+func (r Root) EtcPath(elem ...string) string {
+	return path.Join(append([]string{r.Etc}, elem...)...)
+}
+
+func (r Root) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.fsys(), name)
+}
+
+// ReadLines returns the lines of name without their trailing newline.
+func (r Root) ReadLines(name string) ([]string, error) {
+	return r.readLinesOffsetN(name, 0, -1)
+}
+
+func (r Root) readLinesOffsetN(name string, offset uint, n int) ([]string, error) {
+	f, err := r.fsys().Open(name)
+	if err != nil {
+		return []string{""}, err
+	}
+	defer f.Close()
+
+	var ret []string
+
+	br := bufio.NewReader(f)
+	for i := 0; i < n+int(offset) || n < 0; i++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			// keep a last line that isn't terminated by a newline
+			if line != "" && i >= int(offset) {
+				ret = append(ret, line)
+			}
+			break
+		}
+		if i < int(offset) {
+			continue
+		}
+		ret = append(ret, strings.Trim(line, "\n"))
+	}
+
+	return ret, nil
+}
+
+// ReadString returns the content of name with surrounding whitespace removed,
+// which is how single value files in sysfs and cgroupfs are read.
+func (r Root) ReadString(name string) (string, error) {
+	content, err := r.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(content)), nil
+}
+
+func (r Root) Glob(pattern string) ([]string, error) {
+	return fs.Glob(r.fsys(), pattern)
+}
+
+func (r Root) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(r.fsys(), name)
+}
+
+func (r Root) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.fsys(), name)
+}
+
+// ReadLinkFS is implemented by file systems that can read symbolic links,
+// such as /proc/[pid]/exe, through a Root.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// ReadLink returns the target of the symbolic link name. The host filesystem
+// always supports it, an FS only if it implements ReadLinkFS.
+func (r Root) ReadLink(name string) (string, error) {
+	if r.FS == nil {
+		return os.Readlink(filepath.FromSlash("/" + name))
+	}
+	if fsys, ok := r.FS.(ReadLinkFS); ok {
+		return fsys.ReadLink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: ErrorNotImplemented}
+}
+
+func (r Root) Exists(name string) bool {
+	_, err := r.Stat(name)
+	return err == nil
+}
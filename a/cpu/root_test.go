@@ -0,0 +1,38 @@
+package cpu
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRootReaders(t *testing.T) {
+	root := NewRoot(fstest.MapFS{
+		"proc/loadavg":                  {Data: []byte("0.20 0.18 0.12 1/80 11206\n")},
+		"proc/lines":                    {Data: []byte("a\nb\nc")},
+		"sys/devices/system/cpu/online": {Data: []byte(" 0-3 \n")},
+	})
+
+	if got := root.ProcPath("1", "stat"); got != "proc/1/stat" {
+		t.Errorf("ProcPath = %q", got)
+	}
+	lines, err := root.ReadLines(root.ProcPath("lines"))
+	if err != nil || len(lines) != 3 || lines[2] != "c" {
+		t.Errorf("ReadLines = %q, %v, want the unterminated last line too", lines, err)
+	}
+	lines, err = root.readLinesOffsetN(root.ProcPath("lines"), 1, 1)
+	if err != nil || len(lines) != 1 || lines[0] != "b" {
+		t.Errorf("readLinesOffsetN(1, 1) = %q, %v", lines, err)
+	}
+	online, err := root.ReadString(root.SysPath("devices/system/cpu/online"))
+	if err != nil || online != "0-3" {
+		t.Errorf("ReadString = %q, %v", online, err)
+	}
+	if !root.Exists(root.ProcPath("loadavg")) || root.Exists(root.ProcPath("missing")) {
+		t.Error("Exists is wrong")
+	}
+	if _, err := root.ReadFile(root.ProcPath("missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile of a missing file = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cpu
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrorNoBaseline is returned by samplers called without an interval for the
+// first time, when there is no previous call to compare with.
+var ErrorNoBaseline = errors.New("no sample of a previous call to compare with")
+
+// Baseline holds the sample of the previous call of a sampler for its
+// "since last call" mode. The zero value holds none. It must not be copied.
+type Baseline[T any] struct {
+	mu   sync.Mutex
+	last Sample[T]
+	set  bool
+}
+
+// Sample is a value read by SampleInterval and when it was read.
+type Sample[T any] struct {
+	Value T
+	At    time.Time
+}
+
+// swap stores s and returns the previous sample, if there was one.
+func (b *Baseline[T]) swap(s Sample[T]) (Sample[T], bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev, ok := b.last, b.set
+	b.last, b.set = s, true
+	return prev, ok
+}
+
+// SampleInterval calls read twice, interval apart, and returns both samples.
+// ctx is only used for cancellation. If interval is 0 or less, the first
+// sample is the one stored in last by the previous such call and the new one
+// takes its place; on the first call that fails with ErrorNoBaseline.
+func SampleInterval[T any](ctx context.Context, interval time.Duration, last *Baseline[T], read func() (T, error)) (Sample[T], Sample[T], error) {
+	var s1, s2 Sample[T]
+	var err error
+	if s1.Value, err = read(); err != nil {
+		return s1, s2, err
+	}
+	s1.At = time.Now()
+
+	if interval <= 0 {
+		prev, ok := last.swap(s1)
+		if !ok {
+			return s1, s2, ErrorNoBaseline
+		}
+		return prev, s1, nil
+	}
+
+	if err := Sleep(ctx, interval); err != nil {
+		return s1, s2, err
+	}
+
+	if s2.Value, err = read(); err != nil {
+		return s1, s2, err
+	}
+	s2.At = time.Now()
+	return s1, s2, nil
+}
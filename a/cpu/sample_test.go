@@ -0,0 +1,40 @@
+package cpu
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSampleInterval(t *testing.T) {
+	var last Baseline[int]
+	n := 0
+	read := func() (int, error) {
+		n++
+		return n, nil
+	}
+	ctx := context.Background()
+
+	if _, _, err := SampleInterval(ctx, 0, &last, read); err != ErrorNoBaseline {
+		t.Fatalf("first call = %v, want ErrorNoBaseline", err)
+	}
+	s1, s2, err := SampleInterval(ctx, 0, &last, read)
+	if err != nil || s1.Value != 1 || s2.Value != 2 || s2.At.Before(s1.At) {
+		t.Errorf("since last call = %+v, %+v, %v, want 1 and 2", s1, s2, err)
+	}
+
+	// an interval reads twice and leaves the baseline alone
+	s1, s2, err = SampleInterval(ctx, time.Millisecond, &last, read)
+	if err != nil || s1.Value != 3 || s2.Value != 4 || s2.At.Sub(s1.At) < time.Millisecond {
+		t.Errorf("interval = %+v, %+v, %v, want 3 and 4", s1, s2, err)
+	}
+	if s1, s2, err = SampleInterval(ctx, 0, &last, read); err != nil || s1.Value != 2 || s2.Value != 5 {
+		t.Errorf("since last call = %+v, %+v, %v, want 2 and 5", s1, s2, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := SampleInterval(canceled, time.Hour, &last, read); err != context.Canceled {
+		t.Errorf("canceled = %v, want context.Canceled", err)
+	}
+}
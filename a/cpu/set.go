@@ -0,0 +1,326 @@
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Set is a set of logical CPU numbers, as found in the list format of sysfs
+// and cgroup files ("0-3,8-11") or the hex mask format of
+// /proc/irq/*/smp_affinity ("00000f0f"). The zero value is an empty set.
+// Operations return new sets and never modify their operands, and Add and
+// Remove copy the set before changing it, so copies never share content.
+type Set struct {
+	words []uint64
+}
+
+// maxSetCPU bounds the CPU numbers ParseSet and ParseMask accept, far above
+// any kernel's NR_CPUS, so that untrusted input like "0-2147483647" can't
+// make them loop and allocate for billions of CPUs.
+const maxSetCPU = 1 << 16
+
+func NewSet(cpus ...int) Set {
+	var s Set
+	for _, cpu := range cpus {
+		s.add(cpu)
+	}
+	return s
+}
+
+// ParseSet parses the list format, e.g. "0-3,8-11". Whitespace around the
+// list is ignored and an empty string is an empty set.
+func ParseSet(list string) (Set, error) {
+	var s Set
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return s, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return Set{}, fmt.Errorf("invalid cpu list %q: %w", list, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return Set{}, fmt.Errorf("invalid cpu list %q: %w", list, err)
+			}
+		}
+		if first < 0 || last < first {
+			return Set{}, fmt.Errorf("invalid cpu list %q", list)
+		}
+		if last >= maxSetCPU {
+			return Set{}, fmt.Errorf("invalid cpu list %q: cpu %d out of range", list, last)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			s.add(cpu)
+		}
+	}
+	return s, nil
+}
+
+// ParseMask parses the bitmask format, comma separated 32 bit hex words with
+// the most significant word first, e.g. "ff,00000001".
+func ParseMask(mask string) (Set, error) {
+	var s Set
+	mask = strings.TrimSpace(mask)
+	if mask == "" {
+		return s, nil
+	}
+	words := strings.Split(mask, ",")
+	for i, word := range words {
+		v, err := strconv.ParseUint(word, 16, 32)
+		if err != nil {
+			return Set{}, fmt.Errorf("invalid cpu mask %q: %w", mask, err)
+		}
+		base := (len(words) - 1 - i) * 32
+		if v != 0 && base+bits.Len64(v) > maxSetCPU {
+			return Set{}, fmt.Errorf("invalid cpu mask %q: cpu %d out of range", mask, base+bits.Len64(v)-1)
+		}
+		for v != 0 {
+			bit := bits.TrailingZeros64(v)
+			s.add(base + bit)
+			v &^= 1 << uint(bit)
+		}
+	}
+	return s, nil
+}
+
+// Add puts cpu into the set. Negative numbers are ignored. The set is
+// copied first, so copies of s made earlier keep their content.
+func (s *Set) Add(cpu int) {
+	if cpu < 0 {
+		return
+	}
+	n := len(s.words)
+	if cpu/64 >= n {
+		n = cpu/64 + 1
+	}
+	s.words = s.clone(n)
+	s.add(cpu)
+}
+
+// Remove takes cpu out of the set, copying it first like Add.
+func (s *Set) Remove(cpu int) {
+	if cpu < 0 || cpu/64 >= len(s.words) {
+		return
+	}
+	s.words = s.clone(len(s.words))
+	s.words[cpu/64] &^= 1 << uint(cpu%64)
+}
+
+// add is Add without the copy, for sets that aren't shared yet.
+func (s *Set) add(cpu int) {
+	if cpu < 0 {
+		return
+	}
+	word := cpu / 64
+	for len(s.words) <= word {
+		s.words = append(s.words, 0)
+	}
+	s.words[word] |= 1 << uint(cpu%64)
+}
+
+// clone returns a copy of the words with room for at least n of them.
+func (s Set) clone(n int) []uint64 {
+	if n < len(s.words) {
+		n = len(s.words)
+	}
+	words := make([]uint64, n)
+	copy(words, s.words)
+	return words
+}
+
+func (s Set) Contains(cpu int) bool {
+	if cpu < 0 || cpu/64 >= len(s.words) {
+		return false
+	}
+	return s.words[cpu/64]&(1<<uint(cpu%64)) != 0
+}
+
+func (s Set) Len() int {
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+func (s Set) IsEmpty() bool {
+	return s.Len() == 0
+}
+
+func (s Set) Equal(o Set) bool {
+	return s.Difference(o).IsEmpty() && o.Difference(s).IsEmpty()
+}
+
+// ForEach calls fn for every CPU in the set in ascending order.
+func (s Set) ForEach(fn func(cpu int)) {
+	for i, w := range s.words {
+		for w != 0 {
+			bit := bits.TrailingZeros64(w)
+			fn(i*64 + bit)
+			w &^= 1 << uint(bit)
+		}
+	}
+}
+
+// List returns the CPUs in ascending order.
+func (s Set) List() []int {
+	ret := make([]int, 0, s.Len())
+	s.ForEach(func(cpu int) {
+		ret = append(ret, cpu)
+	})
+	return ret
+}
+
+// First returns the lowest CPU in the set, or -1 if it is empty.
+func (s Set) First() int {
+	for i, w := range s.words {
+		if w != 0 {
+			return i*64 + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+func (s Set) Union(o Set) Set {
+	n := len(s.words)
+	if len(o.words) > n {
+		n = len(o.words)
+	}
+	ret := Set{words: make([]uint64, n)}
+	copy(ret.words, s.words)
+	for i, w := range o.words {
+		ret.words[i] |= w
+	}
+	return ret
+}
+
+func (s Set) Intersect(o Set) Set {
+	n := len(s.words)
+	if len(o.words) < n {
+		n = len(o.words)
+	}
+	ret := Set{words: make([]uint64, n)}
+	for i := range ret.words {
+		ret.words[i] = s.words[i] & o.words[i]
+	}
+	return ret
+}
+
+func (s Set) Difference(o Set) Set {
+	ret := Set{words: make([]uint64, len(s.words))}
+	copy(ret.words, s.words)
+	for i := 0; i < len(ret.words) && i < len(o.words); i++ {
+		ret.words[i] &^= o.words[i]
+	}
+	return ret
+}
+
+// String returns the set in list format, e.g. "0-3,8".
+func (s Set) String() string {
+	var b strings.Builder
+	start, prev := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(start))
+		if prev > start {
+			b.WriteByte('-')
+			b.WriteString(strconv.Itoa(prev))
+		}
+	}
+	s.ForEach(func(cpu int) {
+		if cpu != prev+1 || start < 0 {
+			flush()
+			start = cpu
+		}
+		prev = cpu
+	})
+	flush()
+	return b.String()
+}
+
+// Mask returns the set in the bitmask format of /proc/irq/*/smp_affinity.
+func (s Set) Mask() string {
+	n := s.last()/32 + 1 // number of 32 bit words, at least one
+	words := make([]string, n)
+	for i := 0; i < n; i++ {
+		word := uint32(0)
+		if i/2 < len(s.words) {
+			word = uint32(s.words[i/2] >> (uint(i%2) * 32))
+		}
+		words[n-1-i] = fmt.Sprintf("%08x", word)
+	}
+	return strings.Join(words, ",")
+}
+
+// last returns the highest CPU in the set, or -1 if it is empty.
+func (s Set) last() int {
+	for i := len(s.words) - 1; i >= 0; i-- {
+		if s.words[i] != 0 {
+			return i*64 + 63 - bits.LeadingZeros64(s.words[i])
+		}
+	}
+	return -1
+}
+
+func (s Set) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Set) UnmarshalText(text []byte) error {
+	parsed, err := ParseSet(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Online returns the CPUs that are online and schedulable.
+func Online() (Set, error) {
+	return OnlineWithContext(context.Background())
+}
+
+func OnlineWithContext(ctx context.Context) (Set, error) {
+	return defaultCollector.OnlineWithContext(ctx)
+}
+
+// Possible returns the CPUs that could ever be brought online, including
+// ones that may be hotplugged later.
+func Possible() (Set, error) {
+	return PossibleWithContext(context.Background())
+}
+
+func PossibleWithContext(ctx context.Context) (Set, error) {
+	return defaultCollector.PossibleWithContext(ctx)
+}
+
+// Present returns the CPUs that are physically present in the system.
+func Present() (Set, error) {
+	return PresentWithContext(context.Background())
+}
+
+func PresentWithContext(ctx context.Context) (Set, error) {
+	return defaultCollector.PresentWithContext(ctx)
+}
+
+// Offline returns the possible CPUs that are currently offline.
+func Offline() (Set, error) {
+	return OfflineWithContext(context.Background())
+}
+
+func OfflineWithContext(ctx context.Context) (Set, error) {
+	return defaultCollector.OfflineWithContext(ctx)
+}
@@ -0,0 +1,31 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import "context"
+
+func (c *Collector) OnlineWithContext(ctx context.Context) (Set, error) {
+	return c.readSysCPUSet("online")
+}
+
+func (c *Collector) PossibleWithContext(ctx context.Context) (Set, error) {
+	return c.readSysCPUSet("possible")
+}
+
+func (c *Collector) PresentWithContext(ctx context.Context) (Set, error) {
+	return c.readSysCPUSet("present")
+}
+
+func (c *Collector) OfflineWithContext(ctx context.Context) (Set, error) {
+	return c.readSysCPUSet("offline")
+}
+
+func (c *Collector) readSysCPUSet(name string) (Set, error) {
+	root := c.Root()
+	list, err := root.ReadString(root.SysPath("devices/system/cpu", name))
+	if err != nil {
+		return Set{}, err
+	}
+	return ParseSet(list)
+}
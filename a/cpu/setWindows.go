@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) OnlineWithContext(ctx context.Context) (Set, error) {
+	return Set{}, ErrorNotImplemented
+}
+
+func (c *Collector) PossibleWithContext(ctx context.Context) (Set, error) {
+	return Set{}, ErrorNotImplemented
+}
+
+func (c *Collector) PresentWithContext(ctx context.Context) (Set, error) {
+	return Set{}, ErrorNotImplemented
+}
+
+func (c *Collector) OfflineWithContext(ctx context.Context) (Set, error) {
+	return Set{}, ErrorNotImplemented
+}
@@ -0,0 +1,138 @@
+package cpu
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseSet(t *testing.T) {
+	cases := []struct {
+		list    string
+		want    []int
+		str     string
+		wantErr bool
+	}{
+		{list: "", want: []int{}, str: ""},
+		{list: " \n", want: []int{}, str: ""},
+		{list: "0", want: []int{0}, str: "0"},
+		{list: "0-3,8-11\n", want: []int{0, 1, 2, 3, 8, 9, 10, 11}, str: "0-3,8-11"},
+		{list: "3,1,2", want: []int{1, 2, 3}, str: "1-3"},
+		{list: "0,2,4", want: []int{0, 2, 4}, str: "0,2,4"},
+		{list: "62-65", want: []int{62, 63, 64, 65}, str: "62-65"},
+		{list: "3-1", wantErr: true},
+		{list: "-1", wantErr: true},
+		{list: "a-b", wantErr: true},
+		{list: "1,,2", wantErr: true},
+		{list: "65535", want: []int{65535}, str: "65535"},
+		{list: "65536", wantErr: true},
+		{list: "0-2147483647", wantErr: true},
+		{list: "0-99999999999999999999", wantErr: true},
+	}
+	for _, c := range cases {
+		s, err := ParseSet(c.list)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseSet(%q) = %v, want error", c.list, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSet(%q): %v", c.list, err)
+			continue
+		}
+		if !equalInts(s.List(), c.want) {
+			t.Errorf("ParseSet(%q) = %v, want %v", c.list, s.List(), c.want)
+		}
+		if got := s.String(); got != c.str {
+			t.Errorf("ParseSet(%q).String() = %q, want %q", c.list, got, c.str)
+		}
+	}
+}
+
+func TestParseMask(t *testing.T) {
+	cases := []struct {
+		mask    string
+		list    string
+		out     string
+		wantErr bool
+	}{
+		{mask: "", list: "", out: "00000000"},
+		{mask: "00000000", list: "", out: "00000000"},
+		{mask: "0000000f\n", list: "0-3", out: "0000000f"},
+		{mask: "00000f0f", list: "0-3,8-11", out: "00000f0f"},
+		{mask: "ff,00000001", list: "0,32-39", out: "000000ff,00000001"},
+		{mask: "80000000,00000000,00000000", list: "95", out: "80000000,00000000,00000000"},
+		{mask: "xyz", wantErr: true},
+		{mask: "1ffffffff", wantErr: true},
+		{mask: "1" + strings.Repeat(",00000000", 2048), wantErr: true},
+	}
+	for _, c := range cases {
+		s, err := ParseMask(c.mask)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseMask(%q) = %v, want error", c.mask, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMask(%q): %v", c.mask, err)
+			continue
+		}
+		if got := s.String(); got != c.list {
+			t.Errorf("ParseMask(%q) = %q, want %q", c.mask, got, c.list)
+		}
+		if got := s.Mask(); got != c.out {
+			t.Errorf("ParseMask(%q).Mask() = %q, want %q", c.mask, got, c.out)
+		}
+		back, err := ParseMask(s.Mask())
+		if err != nil || !back.Equal(s) {
+			t.Errorf("ParseMask(%q) does not round-trip: %v, %v", s.Mask(), back, err)
+		}
+	}
+}
+
+func TestSetUnmarshalHugeRange(t *testing.T) {
+	var s Set
+	if err := json.Unmarshal([]byte(`"0-2147483647"`), &s); err == nil {
+		t.Errorf("json.Unmarshal of a huge range = %v, want error", s)
+	}
+}
+
+func TestSetCopiesDontShare(t *testing.T) {
+	a := NewSet(0, 1)
+	b := a
+	b.Add(5)
+	c := b
+	c.Remove(0)
+	if a.String() != "0-1" || b.String() != "0-1,5" || c.String() != "1,5" {
+		t.Errorf("copies share content: a=%v b=%v c=%v", a, b, c)
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	in := NewSet(0, 1, 2, 7)
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"0-2,7"` {
+		t.Errorf("json.Marshal = %s", data)
+	}
+	var out Set
+	if err := json.Unmarshal(data, &out); err != nil || !out.Equal(in) {
+		t.Errorf("json.Unmarshal(%s) = %v, %v", data, out, err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package cpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SystemStat holds everything in /proc/stat. Counters are cumulative since
+// boot, except ProcsRunning and ProcsBlocked which are current values.
+type SystemStat struct {
+	CPU    TimesStat   `json:"cpu"`
+	PerCPU []TimesStat `json:"perCpu"`
+	// Ctxt is the number of context switches.
+	Ctxt uint64 `json:"ctxt"`
+	// BootTime is the boot time in seconds since the epoch.
+	BootTime uint64 `json:"bootTime"`
+	// Processes is the number of forks.
+	Processes    uint64 `json:"processes"`
+	ProcsRunning uint64 `json:"procsRunning"`
+	ProcsBlocked uint64 `json:"procsBlocked"`
+	// Intr is the total number of interrupts, IntrPerIRQ the count for
+	// every numbered interrupt.
+	Intr       uint64   `json:"intr"`
+	IntrPerIRQ []uint64 `json:"intrPerIrq"`
+	// Softirq is the total number of softirqs, SoftirqPerType the count for
+	// every softirq type in kernel order (HI, TIMER, NET_TX, ...).
+	Softirq        uint64   `json:"softirq"`
+	SoftirqPerType []uint64 `json:"softirqPerType"`
+	// Timestamp is when the file was read.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SystemRateStat holds the per second rates of the SystemStat counters
+// between two samples.
+type SystemRateStat struct {
+	CtxtPerSec      float64 `json:"ctxtPerSec"`
+	ForksPerSec     float64 `json:"forksPerSec"`
+	IntrPerSec      float64 `json:"intrPerSec"`
+	SoftirqPerSec   float64 `json:"softirqPerSec"`
+	ProcsRunning    uint64  `json:"procsRunning"`
+	ProcsBlocked    uint64  `json:"procsBlocked"`
+	IntervalSeconds float64 `json:"intervalSeconds"`
+}
+
+var ErrorCounterDecreased = errors.New("counter decreased between samples")
+
+func SystemStatWithContext(ctx context.Context) (*SystemStat, error) {
+	return defaultCollector.SystemStatWithContext(ctx)
+}
+
+func SystemRateWithContext(ctx context.Context) (*SystemRateStat, error) {
+	return defaultCollector.SystemRateWithContext(ctx)
+}
+
+func SystemRateInterval(ctx context.Context, interval time.Duration) (*SystemRateStat, error) {
+	return defaultCollector.SystemRateInterval(ctx, interval)
+}
+
+// CalculateSystemRate returns the rates between s1 and the later sample s2,
+// using the time that passed between reading them.
+func CalculateSystemRate(s1, s2 *SystemStat) (*SystemRateStat, error) {
+	elapsed := s2.Timestamp.Sub(s1.Timestamp).Seconds()
+	if elapsed <= 0 {
+		return nil, fmt.Errorf("samples are not in order: %v, %v", s1.Timestamp, s2.Timestamp)
+	}
+	if s2.Ctxt < s1.Ctxt || s2.Processes < s1.Processes || s2.Intr < s1.Intr || s2.Softirq < s1.Softirq {
+		return nil, ErrorCounterDecreased
+	}
+	return &SystemRateStat{
+		CtxtPerSec:      float64(s2.Ctxt-s1.Ctxt) / elapsed,
+		ForksPerSec:     float64(s2.Processes-s1.Processes) / elapsed,
+		IntrPerSec:      float64(s2.Intr-s1.Intr) / elapsed,
+		SoftirqPerSec:   float64(s2.Softirq-s1.Softirq) / elapsed,
+		ProcsRunning:    s2.ProcsRunning,
+		ProcsBlocked:    s2.ProcsBlocked,
+		IntervalSeconds: elapsed,
+	}, nil
+}
+
+func (c *Collector) SystemRateWithContext(ctx context.Context) (*SystemRateStat, error) {
+	return c.SystemRateInterval(ctx, GetTimeoutDuration(ctx))
+}
+
+// SystemRateInterval reads /proc/stat twice, interval apart. If interval is
+// 0 or less the rates are relative to the previous call.
+func (c *Collector) SystemRateInterval(ctx context.Context, interval time.Duration) (*SystemRateStat, error) {
+	s1, s2, err := SampleInterval(ctx, interval, &c.last.systemStat, func() (*SystemStat, error) {
+		return c.SystemStatWithContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CalculateSystemRate(s1.Value, s2.Value)
+}
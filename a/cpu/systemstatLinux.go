@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (c *Collector) SystemStatWithContext(ctx context.Context) (*SystemStat, error) {
+	root := c.Root()
+	lines, err := root.ReadLines(root.ProcPath("stat"))
+	if err != nil {
+		return nil, err
+	}
+	ret, err := parseSystemStat(lines, c.clocksPerSec())
+	if err != nil {
+		return nil, err
+	}
+	ret.Timestamp = time.Now()
+	return ret, nil
+}
+
+func parseSystemStat(lines []string, clocksPerSec float64) (*SystemStat, error) {
+	ret := &SystemStat{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "cpu") {
+			ct, err := parseStatLine(line, clocksPerSec)
+			if err != nil {
+				return nil, err
+			}
+			if fields[0] == "cpu" {
+				ret.CPU = *ct
+			} else {
+				ret.PerCPU = append(ret.PerCPU, *ct)
+			}
+			continue
+		}
+
+		values := make([]uint64, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %s in /proc/stat: %w", fields[0], err)
+			}
+			values = append(values, v)
+		}
+
+		switch fields[0] {
+		case "ctxt":
+			ret.Ctxt = values[0]
+		case "btime":
+			ret.BootTime = values[0]
+		case "processes":
+			ret.Processes = values[0]
+		case "procs_running":
+			ret.ProcsRunning = values[0]
+		case "procs_blocked":
+			ret.ProcsBlocked = values[0]
+		case "intr":
+			ret.Intr = values[0]
+			ret.IntrPerIRQ = values[1:]
+		case "softirq":
+			ret.Softirq = values[0]
+			ret.SoftirqPerType = values[1:]
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSystemStatFixture(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/stat": {Data: []byte("cpu  300 10 200 4000 50 5 6 0 0 0\n" +
+			"cpu0 150 5 100 2000 25 3 3 0 0 0\n" +
+			"cpu1 150 5 100 2000 25 2 3 0 0 0\n" +
+			"intr 1000 20 0 980\n" +
+			"ctxt 5000\n" +
+			"btime 1700000000\n" +
+			"processes 321\n" +
+			"procs_running 2\n" +
+			"procs_blocked 1\n" +
+			"softirq 600 1 200 3 4 5 6 7 8 9 357\n")},
+	}
+	c := NewCollector(WithRoot(NewRoot(fsys)), WithClocksPerSec(100))
+	s, err := c.SystemStatWithContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.CPU.CPU != "cpu-total" || s.CPU.User != 3 || s.CPU.Idle != 40 {
+		t.Errorf("CPU = %+v", s.CPU)
+	}
+	if len(s.PerCPU) != 2 || s.PerCPU[1].CPU != "cpu1" || s.PerCPU[1].Irq != 0.02 {
+		t.Errorf("PerCPU = %+v", s.PerCPU)
+	}
+	if s.Ctxt != 5000 || s.BootTime != 1700000000 || s.Processes != 321 ||
+		s.ProcsRunning != 2 || s.ProcsBlocked != 1 {
+		t.Errorf("counters = %+v", s)
+	}
+	if s.Intr != 1000 || !reflect.DeepEqual(s.IntrPerIRQ, []uint64{20, 0, 980}) {
+		t.Errorf("intr = %d %v", s.Intr, s.IntrPerIRQ)
+	}
+	if s.Softirq != 600 || !reflect.DeepEqual(s.SoftirqPerType, []uint64{1, 200, 3, 4, 5, 6, 7, 8, 9, 357}) {
+		t.Errorf("softirq = %d %v", s.Softirq, s.SoftirqPerType)
+	}
+	if s.Timestamp.IsZero() {
+		t.Error("Timestamp is not set")
+	}
+
+	fsys["proc/stat"] = &fstest.MapFile{Data: []byte("ctxt 12x\n")}
+	if s, err := c.SystemStatWithContext(context.Background()); err == nil {
+		t.Errorf("SystemStatWithContext of a bad counter = %+v, want error", s)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) SystemStatWithContext(ctx context.Context) (*SystemStat, error) {
+	return nil, ErrorNotImplemented
+}
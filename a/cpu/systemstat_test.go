@@ -0,0 +1,34 @@
+package cpu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateSystemRate(t *testing.T) {
+	t1 := time.Now()
+	s1 := &SystemStat{Ctxt: 1000, Processes: 10, Intr: 500, Softirq: 200, Timestamp: t1}
+	s2 := &SystemStat{Ctxt: 3000, Processes: 14, Intr: 900, Softirq: 300,
+		ProcsRunning: 3, ProcsBlocked: 1, Timestamp: t1.Add(2 * time.Second)}
+
+	rate, err := CalculateSystemRate(s1, s2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SystemRateStat{CtxtPerSec: 1000, ForksPerSec: 2, IntrPerSec: 200, SoftirqPerSec: 50,
+		ProcsRunning: 3, ProcsBlocked: 1, IntervalSeconds: 2}
+	if *rate != want {
+		t.Errorf("CalculateSystemRate = %+v, want %+v", *rate, want)
+	}
+
+	if _, err := CalculateSystemRate(s2, s1); err == nil {
+		t.Error("CalculateSystemRate of samples out of order must fail")
+	}
+
+	// the interrupt counter wrapped, e.g. on a 32 bit kernel
+	s3 := *s2
+	s3.Intr = 100
+	if rate, err := CalculateSystemRate(s1, &s3); err != ErrorCounterDecreased {
+		t.Errorf("CalculateSystemRate of a decreased counter = %+v, %v, want ErrorCounterDecreased", rate, err)
+	}
+}
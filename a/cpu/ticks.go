@@ -0,0 +1,127 @@
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// TicksStat holds the raw cumulative counters of a CPU, in USER_HZ ticks as
+// read from /proc/stat on Linux and in 100ns units on Windows. Deltas
+// between two samples are exact, unlike those of the float seconds in
+// TimesStat.
+type TicksStat struct {
+	CPU       string `json:"cpu"`
+	User      uint64 `json:"user"`
+	Nice      uint64 `json:"nice"`
+	System    uint64 `json:"system"`
+	Idle      uint64 `json:"idle"`
+	Iowait    uint64 `json:"iowait"`
+	Irq       uint64 `json:"irq"`
+	Softirq   uint64 `json:"softirq"`
+	Steal     uint64 `json:"steal"`
+	Guest     uint64 `json:"guest"`
+	GuestNice uint64 `json:"guestNice"`
+}
+
+// CounterResetError reports a cumulative counter that went backwards between
+// two samples, as happens after a VM migration, a snapshot restore or when
+// the proc root changes. Deltas across such samples are meaningless.
+type CounterResetError struct {
+	CPU   string
+	Field string
+}
+
+func (e *CounterResetError) Error() string {
+	return fmt.Sprintf("%s counter of %s decreased between samples", e.Field, e.CPU)
+}
+
+func (e *CounterResetError) Is(target error) bool {
+	return target == ErrorCounterDecreased
+}
+
+func TicksWithContext(ctx context.Context, percpu bool) ([]TicksStat, error) {
+	return defaultCollector.TicksWithContext(ctx, percpu)
+}
+
+// Total returns all ticks. Guest time is already part of User and Nice.
+func (t TicksStat) Total() uint64 {
+	return t.User + t.Nice + t.System + t.Idle + t.Iowait + t.Irq +
+		t.Softirq + t.Steal
+}
+
+// Busy returns all ticks not spent idle.
+func (t TicksStat) Busy() uint64 {
+	return t.Total() - t.Idle
+}
+
+// Times converts the ticks to seconds.
+func (t TicksStat) Times(clocksPerSec float64) TimesStat {
+	return TimesStat{
+		CPU:       t.CPU,
+		User:      float64(t.User) / clocksPerSec,
+		Nice:      float64(t.Nice) / clocksPerSec,
+		System:    float64(t.System) / clocksPerSec,
+		Idle:      float64(t.Idle) / clocksPerSec,
+		Iowait:    float64(t.Iowait) / clocksPerSec,
+		Irq:       float64(t.Irq) / clocksPerSec,
+		Softirq:   float64(t.Softirq) / clocksPerSec,
+		Steal:     float64(t.Steal) / clocksPerSec,
+		Guest:     float64(t.Guest) / clocksPerSec,
+		GuestNice: float64(t.GuestNice) / clocksPerSec,
+	}
+}
+
+// idleJitter is how many ticks idle may go backwards before it counts as a
+// reset. On NOHZ kernels the idle time of a sleeping CPU is an estimate
+// that a later exact reading can undercut by a few ticks.
+const idleJitter = 10
+
+// Sub returns the ticks elapsed since the earlier sample prev, or a
+// *CounterResetError if a counter decreased. iowait is not reliable and may
+// decrease (see proc(5)), as may idle by up to idleJitter ticks; their delta
+// is 0 then.
+func (t TicksStat) Sub(prev TicksStat) (TicksStat, error) {
+	ret := TicksStat{CPU: t.CPU}
+	for _, f := range []struct {
+		name      string
+		cur, prev uint64
+		delta     *uint64
+		tolerance uint64
+	}{
+		{"user", t.User, prev.User, &ret.User, 0},
+		{"nice", t.Nice, prev.Nice, &ret.Nice, 0},
+		{"system", t.System, prev.System, &ret.System, 0},
+		{"idle", t.Idle, prev.Idle, &ret.Idle, idleJitter},
+		{"iowait", t.Iowait, prev.Iowait, &ret.Iowait, math.MaxUint64},
+		{"irq", t.Irq, prev.Irq, &ret.Irq, 0},
+		{"softirq", t.Softirq, prev.Softirq, &ret.Softirq, 0},
+		{"steal", t.Steal, prev.Steal, &ret.Steal, 0},
+		{"guest", t.Guest, prev.Guest, &ret.Guest, 0},
+		{"guestNice", t.GuestNice, prev.GuestNice, &ret.GuestNice, 0},
+	} {
+		if f.cur < f.prev {
+			if f.prev-f.cur > f.tolerance {
+				return TicksStat{}, &CounterResetError{CPU: t.CPU, Field: f.name}
+			}
+			continue
+		}
+		*f.delta = f.cur - f.prev
+	}
+	return ret, nil
+}
+
+// CalculateTicksBusy returns the busy percentage between the samples t1 and
+// t2 of the same CPU, computed from exact integer deltas.
+func CalculateTicksBusy(t1, t2 TicksStat) (float64, error) {
+	delta, err := t2.Sub(t1)
+	if err != nil {
+		return 0, err
+	}
+	total := delta.Total()
+	if total == 0 {
+		// no tick passed, the CPU can't have been busy
+		return 0, nil
+	}
+	return float64(delta.Busy()) / float64(total) * 100, nil
+}
@@ -0,0 +1,70 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sys/windows"
+	"unsafe"
+)
+
+// TicksWithContext returns the CPU counters in 100ns units. Windows only
+// distinguishes user, kernel, idle and interrupt time; kernel time without
+// idle is reported as System.
+func (c *Collector) TicksWithContext(ctx context.Context, percpu bool) ([]TicksStat, error) {
+	if percpu {
+		return perCPUTicks()
+	}
+
+	var lpIdleTime FILETIME
+	var lpKernelTime FILETIME
+	var lpUserTime FILETIME
+	r, _, _ := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&lpIdleTime)),
+		uintptr(unsafe.Pointer(&lpKernelTime)),
+		uintptr(unsafe.Pointer(&lpUserTime)))
+	if r == 0 {
+		return nil, windows.GetLastError()
+	}
+
+	idle := lpIdleTime.ticks()
+	kernel := lpKernelTime.ticks()
+	return []TicksStat{{
+		CPU:    "cpu-total",
+		Idle:   idle,
+		User:   lpUserTime.ticks(),
+		System: kernelWithoutIdle(kernel, idle),
+	}}, nil
+}
+
+func perCPUTicks() ([]TicksStat, error) {
+	stats, err := perfInfo()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]TicksStat, 0, len(stats))
+	for core, v := range stats {
+		ret = append(ret, TicksStat{
+			CPU:    fmt.Sprintf("cpu%d", core),
+			User:   uint64(v.UserTime),
+			System: kernelWithoutIdle(uint64(v.KernelTime), uint64(v.IdleTime)),
+			Idle:   uint64(v.IdleTime),
+			Irq:    uint64(v.InterruptTime),
+		})
+	}
+	return ret, nil
+}
+
+func (t FILETIME) ticks() uint64 {
+	return uint64(t.DwHighDateTime)<<32 | uint64(t.DwLowDateTime)
+}
+
+// kernelWithoutIdle removes the idle time the kernel time includes.
+func kernelWithoutIdle(kernel, idle uint64) uint64 {
+	if kernel < idle {
+		return 0
+	}
+	return kernel - idle
+}
@@ -0,0 +1,78 @@
+package cpu
+
+import "context"
+
+// TopologyCPU places a logical CPU in the machine. Die is 0 on kernels that
+// don't report dies, Node is -1 if the NUMA node is unknown.
+type TopologyCPU struct {
+	CPU     int `json:"cpu"`
+	Package int `json:"package"`
+	Die     int `json:"die"`
+	Core    int `json:"core"`
+	// Siblings are the logical CPUs sharing the physical core, including CPU.
+	Siblings Set `json:"siblings"`
+	Node     int `json:"node"`
+}
+
+// Topology describes the online logical CPUs, offline ones are left out.
+type Topology struct {
+	CPUs []TopologyCPU `json:"cpus"`
+	// Nodes maps each NUMA node to its logical CPUs.
+	Nodes map[int]Set `json:"nodes"`
+}
+
+// GetTopology is TopologyWithContext without a context. It can't be called
+// Topology, which names the type.
+func GetTopology() (*Topology, error) {
+	return TopologyWithContext(context.Background())
+}
+
+func TopologyWithContext(ctx context.Context) (*Topology, error) {
+	return defaultCollector.TopologyWithContext(ctx)
+}
+
+// CPU returns the placement of the logical CPU cpu.
+func (t *Topology) CPU(cpu int) (TopologyCPU, bool) {
+	for _, c := range t.CPUs {
+		if c.CPU == cpu {
+			return c, true
+		}
+	}
+	return TopologyCPU{}, false
+}
+
+// NodeCPUs returns the logical CPUs of a NUMA node.
+func (t *Topology) NodeCPUs(node int) Set {
+	return t.Nodes[node]
+}
+
+// CoreCPUs returns the logical CPUs of a physical core. Core IDs are only
+// unique within a package and die.
+func (t *Topology) CoreCPUs(pkg, die, core int) Set {
+	var ret Set
+	for _, c := range t.CPUs {
+		if c.Package == pkg && c.Die == die && c.Core == core {
+			ret.Add(c.CPU)
+		}
+	}
+	return ret
+}
+
+// PhysicalCores groups the logical CPUs by physical core, e.g. to add up
+// per-CPU usage per core.
+func (t *Topology) PhysicalCores() []Set {
+	type coreKey struct{ pkg, die, core int }
+	index := make(map[coreKey]int)
+	var ret []Set
+	for _, c := range t.CPUs {
+		key := coreKey{c.Package, c.Die, c.Core}
+		i, ok := index[key]
+		if !ok {
+			i = len(ret)
+			index[key] = i
+			ret = append(ret, Set{})
+		}
+		ret[i].Add(c.CPU)
+	}
+	return ret
+}
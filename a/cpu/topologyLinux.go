@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func (c *Collector) TopologyWithContext(ctx context.Context) (*Topology, error) {
+	root := c.Root()
+
+	cpus := sysCPUNumbers(root)
+	if len(cpus) == 0 {
+		return nil, errors.New("could not find any cpu in sysfs")
+	}
+
+	ret := &Topology{
+		CPUs:  make([]TopologyCPU, 0, len(cpus)),
+		Nodes: make(map[int]Set),
+	}
+
+	nodeDirs, _ := root.Glob(root.SysPath("devices/system/node/node[0-9]*"))
+	nodeOf := make(map[int]int)
+	for _, dir := range nodeDirs {
+		node, err := strconv.Atoi(strings.TrimPrefix(path.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		list, err := root.ReadString(path.Join(dir, "cpulist"))
+		if err != nil {
+			continue
+		}
+		nodeCPUs, err := ParseSet(list)
+		if err != nil {
+			return nil, err
+		}
+		ret.Nodes[node] = nodeCPUs
+		nodeCPUs.ForEach(func(cpu int) {
+			nodeOf[cpu] = node
+		})
+	}
+
+	// offline CPUs have no topology directory, or a stale one on old kernels
+	online, onlineErr := c.readSysCPUSet("online")
+	for _, n := range cpus {
+		cpu := int(n)
+		if onlineErr == nil && !online.Contains(cpu) {
+			continue
+		}
+		if !root.Exists(sysCPUPath(root, n, "topology")) {
+			continue
+		}
+		t := TopologyCPU{CPU: cpu, Node: -1}
+		if node, ok := nodeOf[cpu]; ok {
+			t.Node = node
+		}
+		t.Package, _ = readTopologyInt(root, n, "physical_package_id")
+		t.Die, _ = readTopologyInt(root, n, "die_id")
+		t.Core, _ = readTopologyInt(root, n, "core_id")
+
+		// core_cpus_list replaced thread_siblings_list in Linux 5.3
+		for _, name := range []string{"core_cpus_list", "thread_siblings_list"} {
+			list, err := root.ReadString(sysCPUPath(root, n, path.Join("topology", name)))
+			if err != nil {
+				continue
+			}
+			if t.Siblings, err = ParseSet(list); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if t.Siblings.IsEmpty() {
+			t.Siblings = NewSet(cpu)
+		}
+		ret.CPUs = append(ret.CPUs, t)
+	}
+	return ret, nil
+}
+
+func readTopologyInt(root Root, cpu int32, name string) (int, error) {
+	value, err := root.ReadString(sysCPUPath(root, cpu, path.Join("topology", name)))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
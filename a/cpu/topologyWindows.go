@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import "context"
+
+func (c *Collector) TopologyWithContext(ctx context.Context) (*Topology, error) {
+	return nil, ErrorNotImplemented
+}
@@ -6,9 +6,14 @@ import (
 	"cpuV3/a/process"
 	"os"
 	"sync"
+	"time"
 )
 
 func GetCpuUsage(ctx context.Context) (usage uint32, currentProcessUsage uint32, err error) {
+	return GetCpuUsageInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
+
+func GetCpuUsageInterval(ctx context.Context, interval time.Duration) (usage uint32, currentProcessUsage uint32, err error) {
 	var wg sync.WaitGroup
 	errChan := make(chan error, 3)
 
@@ -17,11 +22,11 @@ func GetCpuUsage(ctx context.Context) (usage uint32, currentProcessUsage uint32,
 	go func() {
 		defer wg.Done()
 
-		resultUsage, err := cpu.PercentWithContext(ctx)
+		resultUsage, err := cpu.PercentInterval(ctx, interval, false)
 		if err != nil {
 			errChan <- err
 			usage = 0
-		} else {
+		} else if len(resultUsage) > 0 {
 			usage = uint32(resultUsage[0])
 		}
 
@@ -37,18 +42,13 @@ func GetCpuUsage(ctx context.Context) (usage uint32, currentProcessUsage uint32,
 			return
 		}
 
-		resultCurrentProcessUsage, err := p.PercentWithContext(ctx)
+		resultCurrentProcessUsage, err := p.NormalizedPercentInterval(ctx, interval)
 		if err != nil {
 			errChan <- err
 			currentProcessUsage = 999
 			return
 		}
-
-		totalLogicalCores, err := cpu.Counts(true)
-		if err != nil {
-			errChan <- err
-		}
-		currentProcessUsage = uint32((resultCurrentProcessUsage / 100) * float64(totalLogicalCores))
+		currentProcessUsage = uint32(resultCurrentProcessUsage)
 	}()
 
 	wg.Wait()
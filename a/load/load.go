@@ -0,0 +1,61 @@
+package load
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+)
+
+// AvgStat is the content of /proc/loadavg.
+type AvgStat struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+	// ProcsRunning is the number of runnable tasks, the run queue length.
+	ProcsRunning int `json:"procsRunning"`
+	// ProcsTotal is the number of tasks that exist on the system.
+	ProcsTotal int   `json:"procsTotal"`
+	LastPid    int32 `json:"lastPid"`
+}
+
+// PerCPUStat is the load average divided by the number of logical CPUs, so
+// that 1 means every CPU had one runnable task on average.
+type PerCPUStat struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+	CPUs   int     `json:"cpus"`
+}
+
+func Avg() (*AvgStat, error) {
+	return AvgWithContext(context.Background())
+}
+
+func AvgWithContext(ctx context.Context) (*AvgStat, error) {
+	return AvgWithRoot(ctx, cpu.DefaultRoot())
+}
+
+func PerCPU() (*PerCPUStat, error) {
+	return PerCPUWithContext(context.Background())
+}
+
+func PerCPUWithContext(ctx context.Context) (*PerCPUStat, error) {
+	return PerCPUWithRoot(ctx, cpu.DefaultRoot())
+}
+
+func PerCPUWithRoot(ctx context.Context, root cpu.Root) (*PerCPUStat, error) {
+	avg, err := AvgWithRoot(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	n, err := cpu.NewCollector(cpu.WithRoot(root)).CountsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	ret := &PerCPUStat{CPUs: n}
+	if n > 0 {
+		ret.Load1 = avg.Load1 / float64(n)
+		ret.Load5 = avg.Load5 / float64(n)
+		ret.Load15 = avg.Load15 / float64(n)
+	}
+	return ret, nil
+}
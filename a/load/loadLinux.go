@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+package load
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func AvgWithRoot(ctx context.Context, root cpu.Root) (*AvgStat, error) {
+	line, err := root.ReadString(root.ProcPath("loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	return parseLoadAvg(line)
+}
+
+// parseLoadAvg parses "0.20 0.18 0.12 1/80 11206".
+func parseLoadAvg(line string) (*AvgStat, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("wrong loadavg format: %q", line)
+	}
+
+	ret := &AvgStat{}
+	var err error
+	if ret.Load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return nil, err
+	}
+	if ret.Load5, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return nil, err
+	}
+	if ret.Load15, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return nil, err
+	}
+
+	procs := strings.SplitN(fields[3], "/", 2)
+	if len(procs) != 2 {
+		return nil, fmt.Errorf("wrong loadavg format: %q", line)
+	}
+	if ret.ProcsRunning, err = strconv.Atoi(procs[0]); err != nil {
+		return nil, err
+	}
+	if ret.ProcsTotal, err = strconv.Atoi(procs[1]); err != nil {
+		return nil, err
+	}
+
+	lastPid, err := strconv.ParseInt(fields[4], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	ret.LastPid = int32(lastPid)
+	return ret, nil
+}
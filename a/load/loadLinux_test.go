@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package load
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseLoadAvg(t *testing.T) {
+	cases := []struct {
+		line    string
+		want    AvgStat
+		wantErr bool
+	}{
+		{
+			line: "0.20 0.18 0.12 1/80 11206\n",
+			want: AvgStat{Load1: 0.2, Load5: 0.18, Load15: 0.12, ProcsRunning: 1, ProcsTotal: 80, LastPid: 11206},
+		},
+		{
+			line: "12.50 8.00 4.25 17/1024 4194303",
+			want: AvgStat{Load1: 12.5, Load5: 8, Load15: 4.25, ProcsRunning: 17, ProcsTotal: 1024, LastPid: 4194303},
+		},
+		{line: "0.20 0.18 0.12 80 11206", wantErr: true},
+		{line: "0.20 0.18 0.12 1-80 11206", wantErr: true},
+		{line: "0.20 0.18 0.12 1/80", wantErr: true},
+		{line: "0.20 0.18", wantErr: true},
+		{line: "", wantErr: true},
+		{line: "0.20 x 0.12 1/80 11206", wantErr: true},
+		{line: "0.20 0.18 0.12 1/x 11206", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := parseLoadAvg(c.line)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseLoadAvg(%q) = %+v, want error", c.line, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseLoadAvg(%q): %v", c.line, err)
+			continue
+		}
+		if *got != c.want {
+			t.Errorf("parseLoadAvg(%q) = %+v, want %+v", c.line, *got, c.want)
+		}
+	}
+}
+
+func TestAvgWithRoot(t *testing.T) {
+	root := cpu.NewRoot(fstest.MapFS{"proc/loadavg": {Data: []byte("1.00 0.50 0.25 2/100 42\n")}})
+	got, err := AvgWithRoot(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Load1 != 1 || got.ProcsTotal != 100 || got.LastPid != 42 {
+		t.Errorf("AvgWithRoot = %+v", got)
+	}
+}
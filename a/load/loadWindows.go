@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package load
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+)
+
+func AvgWithRoot(ctx context.Context, root cpu.Root) (*AvgStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
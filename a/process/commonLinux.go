@@ -6,10 +6,12 @@ package process
 import (
 	"context"
 	"cpuV3/a/cpu"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -24,7 +26,7 @@ var (
 	cachedVirtOnce  sync.Once
 )
 
-func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
+func pidExistsWithContext(ctx context.Context, root cpu.Root, pid int32) (bool, error) {
 	if pid == 0 { // special case for pid 0 System Idle Process
 		return true, nil
 	}
@@ -32,16 +34,16 @@ func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
 		return false, fmt.Errorf("invalid pid %v", pid)
 	}
 
-	procPath := fmt.Sprintf("/proc/%d", pid)
-	if _, err := os.Stat(procPath); err != nil {
-		if os.IsNotExist(err) {
+	procPath := root.ProcPath(strconv.Itoa(int(pid)))
+	if _, err := root.Stat(procPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
 			return false, nil
 		}
 		return false, err
 	}
 
-	stasFile := fmt.Sprintf("/proc/%d/stat", pid)
-	statData, err := ioutil.ReadFile(stasFile)
+	stasFile := root.ProcPath(strconv.Itoa(int(pid)), "stat")
+	statData, err := root.ReadFile(stasFile)
 	if err != nil {
 		return false, err
 	}
@@ -62,8 +64,6 @@ func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
 }
 
 func VirtualizationWithContext(ctx context.Context) (string, string, error) {
-	var system, role string
-
 	// if cached already, return from cache
 	cachedVirtMutex.RLock() // unlock won't be deferred so concurrent reads don't wait for long
 	if cachedVirtMap != nil {
@@ -73,22 +73,45 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 	}
 	cachedVirtMutex.RUnlock()
 
-	filename := cpu.HostProc("xen")
-	if PathExists(filename) {
+	system, role, err := virtualizationWithRoot(cpu.DefaultRoot())
+	if err != nil {
+		return "", "", err
+	}
+
+	// before returning for the first time, cache the system and role
+	cachedVirtOnce.Do(func() {
+		cachedVirtMutex.Lock()
+		defer cachedVirtMutex.Unlock()
+		cachedVirtMap = map[string]string{
+			"system": system,
+			"role":   role,
+		}
+	})
+
+	return system, role, nil
+}
+
+// virtualizationWithRoot detects the virtualization system of the host
+// behind root. Unlike VirtualizationWithContext the result isn't cached.
+func virtualizationWithRoot(root cpu.Root) (string, string, error) {
+	var system, role string
+
+	filename := root.ProcPath("xen")
+	if root.Exists(filename) {
 		system = "xen"
 		role = "guest" // assume guest
 
-		if PathExists(filepath.Join(filename, "capabilities")) {
-			contents, err := cpu.ReadLines(filepath.Join(filename, "capabilities"))
+		if root.Exists(path.Join(filename, "capabilities")) {
+			contents, err := root.ReadLines(path.Join(filename, "capabilities"))
 			if err == nil && StringsContains(contents, "control_d") {
 				role = "host"
 			}
 		}
 	}
 
-	filename = cpu.HostProc("modules")
-	if PathExists(filename) {
-		contents, err := cpu.ReadLines(filename)
+	filename = root.ProcPath("modules")
+	if root.Exists(filename) {
+		contents, err := root.ReadLines(filename)
 		if err == nil {
 			switch {
 			case StringsContains(contents, "kvm"):
@@ -107,9 +130,9 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 		}
 	}
 
-	filename = cpu.HostProc("cpuinfo")
-	if PathExists(filename) {
-		contents, err := cpu.ReadLines(filename)
+	filename = root.ProcPath("cpuinfo")
+	if root.Exists(filename) {
+		contents, err := root.ReadLines(filename)
 		if err == nil {
 			if StringsContains(contents, "QEMU Virtual CPU") ||
 				StringsContains(contents, "Common KVM processor") ||
@@ -120,9 +143,9 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 		}
 	}
 
-	filename = cpu.HostProc("bus/pci/devices")
-	if PathExists(filename) {
-		contents, err := cpu.ReadLines(filename)
+	filename = root.ProcPath("bus/pci/devices")
+	if root.Exists(filename) {
+		contents, err := root.ReadLines(filename)
 		if err == nil {
 			if StringsContains(contents, "virtio-pci") {
 				role = "guest"
@@ -130,18 +153,18 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 		}
 	}
 
-	filename = cpu.HostProc()
-	if PathExists(filepath.Join(filename, "bc", "0")) {
+	filename = root.ProcPath()
+	if root.Exists(path.Join(filename, "bc", "0")) {
 		system = "openvz"
 		role = "host"
-	} else if PathExists(filepath.Join(filename, "vz")) {
+	} else if root.Exists(path.Join(filename, "vz")) {
 		system = "openvz"
 		role = "guest"
 	}
 
 	// not use dmidecode because it requires root
-	if PathExists(filepath.Join(filename, "self", "status")) {
-		contents, err := cpu.ReadLines(filepath.Join(filename, "self", "status"))
+	if root.Exists(path.Join(filename, "self", "status")) {
+		contents, err := root.ReadLines(path.Join(filename, "self", "status"))
 		if err == nil {
 			if StringsContains(contents, "s_context:") ||
 				StringsContains(contents, "VxID:") {
@@ -151,19 +174,19 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 		}
 	}
 
-	if PathExists(filepath.Join(filename, "1", "environ")) {
-		contents, err := ReadFile(filepath.Join(filename, "1", "environ"))
+	if root.Exists(path.Join(filename, "1", "environ")) {
+		contents, err := root.ReadFile(path.Join(filename, "1", "environ"))
 
 		if err == nil {
-			if strings.Contains(contents, "container=lxc") {
+			if strings.Contains(string(contents), "container=lxc") {
 				system = "lxc"
 				role = "guest"
 			}
 		}
 	}
 
-	if PathExists(filepath.Join(filename, "self", "cgroup")) {
-		contents, err := cpu.ReadLines(filepath.Join(filename, "self", "cgroup"))
+	if root.Exists(path.Join(filename, "self", "cgroup")) {
+		contents, err := root.ReadLines(path.Join(filename, "self", "cgroup"))
 		if err == nil {
 			switch {
 			case StringsContains(contents, "lxc"):
@@ -175,39 +198,44 @@ func VirtualizationWithContext(ctx context.Context) (string, string, error) {
 			case StringsContains(contents, "machine-rkt"):
 				system = "rkt"
 				role = "guest"
-			case PathExists("/usr/bin/lxc-version"):
+			// the parent of the etc tree is the filesystem of the host
+			case root.Exists(path.Join(path.Dir(root.Etc), "usr/bin/lxc-version")):
 				system = "lxc"
 				role = "host"
 			}
 		}
 	}
 
-	if PathExists(cpu.HostEtc("os-release")) {
-		p, _, err := GetOSRelease()
+	if root.Exists(root.EtcPath("os-release")) {
+		p, _, err := getOSReleaseWithRoot(root)
 		if err == nil && p == "coreos" {
 			system = "rkt" // Is it true?
 			role = "host"
 		}
 	}
 
-	// before returning for the first time, cache the system and role
-	cachedVirtOnce.Do(func() {
-		cachedVirtMutex.Lock()
-		defer cachedVirtMutex.Unlock()
-		cachedVirtMap = map[string]string{
-			"system": system,
-			"role":   role,
-		}
-	})
-
 	return system, role, nil
 }
 
+// PathExists reports whether the host path filename exists, through
+// cpu.DefaultRoot.
+//
+// Deprecated: use cpu.Root.Exists.
 func PathExists(filename string) bool {
-	if _, err := os.Stat(filename); err == nil {
-		return true
+	return cpu.DefaultRoot().Exists(hostRelative(filename))
+}
+
+// hostRelative turns a host path into one relative to the FS of
+// cpu.DefaultRoot.
+func hostRelative(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
 	}
-	return false
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
 }
 
 func StringsContains(target []string, src string) bool {
@@ -219,18 +247,23 @@ func StringsContains(target []string, src string) bool {
 	return false
 }
 
+// ReadFile reads the host path filename through cpu.DefaultRoot.
+//
+// Deprecated: use cpu.Root.ReadString.
 func ReadFile(filename string) (string, error) {
-	content, err := ioutil.ReadFile(filename)
-
+	content, err := cpu.DefaultRoot().ReadFile(hostRelative(filename))
 	if err != nil {
 		return "", err
 	}
-
 	return string(content), nil
 }
 
 func GetOSRelease() (platform string, version string, err error) {
-	contents, err := cpu.ReadLines(cpu.HostEtc("os-release"))
+	return getOSReleaseWithRoot(cpu.DefaultRoot())
+}
+
+func getOSReleaseWithRoot(root cpu.Root) (platform string, version string, err error) {
+	contents, err := root.ReadLines(root.EtcPath("os-release"))
 	if err != nil {
 		return "", "", nil // return empty
 	}
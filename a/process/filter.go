@@ -0,0 +1,79 @@
+package process
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Filter selects processes in Processes. An error caused by the process
+// exiting makes Processes skip it, any other error fails the call.
+type Filter func(ctx context.Context, p *Process) (bool, error)
+
+// MatchName selects processes whose name matches re.
+func MatchName(re *regexp.Regexp) Filter {
+	return func(ctx context.Context, p *Process) (bool, error) {
+		name, err := p.nameWithContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(name), nil
+	}
+}
+
+// MatchCmdline selects processes whose command line, with the arguments
+// joined by spaces, contains substr.
+func MatchCmdline(substr string) Filter {
+	return func(ctx context.Context, p *Process) (bool, error) {
+		cmdline, err := p.CmdlineWithContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		return strings.Contains(cmdline, substr), nil
+	}
+}
+
+// MatchUid selects processes whose real UID is uid, the owner Username
+// reports.
+func MatchUid(uid int32) Filter {
+	return matchUidIndex(uid, 0)
+}
+
+// MatchEffectiveUid selects processes whose effective UID is uid, e.g. setuid
+// programs running as root.
+func MatchEffectiveUid(uid int32) Filter {
+	return matchUidIndex(uid, 1)
+}
+
+// matchUidIndex compares the UID at index of Uids: real, effective, saved
+// and filesystem UID.
+func matchUidIndex(uid int32, index int) Filter {
+	return func(ctx context.Context, p *Process) (bool, error) {
+		uids, err := p.uidsWithContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		return len(uids) > index && uids[index] == uid, nil
+	}
+}
+
+// MatchPpid selects the children of the process ppid.
+func MatchPpid(ppid int32) Filter {
+	return func(ctx context.Context, p *Process) (bool, error) {
+		parent, err := p.ppidWithContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		return parent == ppid, nil
+	}
+}
+
+func matchAll(ctx context.Context, p *Process, filters []Filter) (bool, error) {
+	for _, filter := range filters {
+		ok, err := filter(ctx, p)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package process
+
+import "context"
+
+// MemoryInfoStat is the memory use of a process in bytes.
+type MemoryInfoStat struct {
+	// RSS is the resident set, VMS the virtual memory size.
+	RSS uint64 `json:"rss"`
+	VMS uint64 `json:"vms"`
+	// Shared is the resident memory backed by files, which other processes
+	// may map too.
+	Shared uint64 `json:"shared"`
+	Text   uint64 `json:"text"`
+	// Data is the data segment plus the stack.
+	Data uint64 `json:"data"`
+	Swap uint64 `json:"swap"`
+	// RSSAnon, RSSFile and RSSShmem split RSS up, they are 0 before
+	// Linux 4.5.
+	RSSAnon  uint64 `json:"rssAnon"`
+	RSSFile  uint64 `json:"rssFile"`
+	RSSShmem uint64 `json:"rssShmem"`
+}
+
+// MemoryInfoExStat is the memory use of a process in bytes, accounting for
+// pages shared with other processes.
+type MemoryInfoExStat struct {
+	RSS uint64 `json:"rss"`
+	// PSS is the proportional set size: every resident page divided by the
+	// number of processes mapping it. Summing it over processes doesn't
+	// count shared pages twice.
+	PSS      uint64 `json:"pss"`
+	PSSAnon  uint64 `json:"pssAnon"`
+	PSSFile  uint64 `json:"pssFile"`
+	PSSShmem uint64 `json:"pssShmem"`
+	// USS is the unique set size, the memory freed if the process exited.
+	USS          uint64 `json:"uss"`
+	SharedClean  uint64 `json:"sharedClean"`
+	SharedDirty  uint64 `json:"sharedDirty"`
+	PrivateClean uint64 `json:"privateClean"`
+	PrivateDirty uint64 `json:"privateDirty"`
+	Swap         uint64 `json:"swap"`
+	SwapPSS      uint64 `json:"swapPss"`
+}
+
+func (p *Process) MemoryInfo() (*MemoryInfoStat, error) {
+	return p.MemoryInfoWithContext(context.Background())
+}
+
+func (p *Process) MemoryInfoWithContext(ctx context.Context) (*MemoryInfoStat, error) {
+	return p.memoryInfoWithContext(ctx)
+}
+
+func (p *Process) MemoryInfoEx() (*MemoryInfoExStat, error) {
+	return p.MemoryInfoExWithContext(context.Background())
+}
+
+func (p *Process) MemoryInfoExWithContext(ctx context.Context) (*MemoryInfoExStat, error) {
+	return p.memoryInfoExWithContext(ctx)
+}
+
+func (p *Process) MemoryPercent() (float64, error) {
+	return p.MemoryPercentWithContext(context.Background())
+}
+
+// MemoryPercentWithContext returns RSS as a percentage of the memory the
+// process may use: the memory limit of its cgroup if there is one, the total
+// RAM otherwise.
+func (p *Process) MemoryPercentWithContext(ctx context.Context) (float64, error) {
+	mem, err := p.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	limit, err := p.memoryLimitWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 {
+		return 0, nil
+	}
+	return float64(mem.RSS) / float64(limit) * 100, nil
+}
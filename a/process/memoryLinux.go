@@ -0,0 +1,157 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func (p *Process) memoryInfoWithContext(ctx context.Context) (*MemoryInfoStat, error) {
+	root := p.hostRoot()
+	pid := strconv.Itoa(int(p.Pid))
+
+	line, err := root.ReadString(root.ProcPath(pid, "statm"))
+	if err != nil {
+		return nil, err
+	}
+	// size resident shared text lib data dt, in pages
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("wrong statm format: %q", line)
+	}
+	pages := make([]uint64, 6)
+	for i := range pages {
+		if pages[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	pageSize := uint64(os.Getpagesize())
+	ret := &MemoryInfoStat{
+		VMS:    pages[0] * pageSize,
+		RSS:    pages[1] * pageSize,
+		Shared: pages[2] * pageSize,
+		Text:   pages[3] * pageSize,
+		Data:   pages[5] * pageSize,
+	}
+
+	lines, err := root.ReadLines(root.ProcPath(pid, "status"))
+	if err != nil {
+		return nil, err
+	}
+	err = parseKBLines(lines, map[string]*uint64{
+		"VmSwap":   &ret.Swap,
+		"RssAnon":  &ret.RSSAnon,
+		"RssFile":  &ret.RSSFile,
+		"RssShmem": &ret.RSSShmem,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// memoryInfoExWithContext reads smaps_rollup, or sums up smaps before
+// Linux 4.14.
+func (p *Process) memoryInfoExWithContext(ctx context.Context) (*MemoryInfoExStat, error) {
+	root := p.hostRoot()
+	pid := strconv.Itoa(int(p.Pid))
+
+	lines, err := root.ReadLines(root.ProcPath(pid, "smaps_rollup"))
+	if errors.Is(err, fs.ErrNotExist) {
+		lines, err = root.ReadLines(root.ProcPath(pid, "smaps"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &MemoryInfoExStat{}
+	err = parseKBLines(lines, map[string]*uint64{
+		"Rss":           &ret.RSS,
+		"Pss":           &ret.PSS,
+		"Pss_Anon":      &ret.PSSAnon,
+		"Pss_File":      &ret.PSSFile,
+		"Pss_Shmem":     &ret.PSSShmem,
+		"Shared_Clean":  &ret.SharedClean,
+		"Shared_Dirty":  &ret.SharedDirty,
+		"Private_Clean": &ret.PrivateClean,
+		"Private_Dirty": &ret.PrivateDirty,
+		"Swap":          &ret.Swap,
+		"SwapPss":       &ret.SwapPSS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret.USS = ret.PrivateClean + ret.PrivateDirty
+	return ret, nil
+}
+
+// parseKBLines adds the values of "Key:   123 kB" lines to the matching
+// entry of fields, in bytes. Keys that appear several times, as in smaps,
+// are summed.
+func parseKBLines(lines []string, fields map[string]*uint64) error {
+	for _, line := range lines {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		field, ok := fields[kv[0]]
+		if !ok {
+			continue
+		}
+		value := strings.TrimSuffix(strings.TrimSpace(kv[1]), " kB")
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("wrong format of %s: %w", kv[0], err)
+		}
+		*field += v * 1024
+	}
+	return nil
+}
+
+// memoryLimitWithContext returns the memory limit of the cgroup of the
+// process, or MemTotal if it is unlimited or lower.
+func (p *Process) memoryLimitWithContext(ctx context.Context) (uint64, error) {
+	root := p.hostRoot()
+	lines, err := root.ReadLines(root.ProcPath("meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	var total uint64
+	if err := parseKBLines(lines, map[string]*uint64{"MemTotal": &total}); err != nil {
+		return 0, err
+	}
+
+	if limit, ok := cgroupMemoryLimit(root, p.Pid); ok && limit < total {
+		return limit, nil
+	}
+	return total, nil
+}
+
+func cgroupMemoryLimit(root cpu.Root, pid int32) (uint64, bool) {
+	c := cpu.NewCollector(cpu.WithRoot(root))
+	if dir, err := c.ProcessCgroupPath(pid, "memory"); err == nil {
+		// v1 reports no limit as a huge number, which MemTotal undercuts
+		if limit, err := root.ReadString(path.Join(dir, "memory.limit_in_bytes")); err == nil {
+			v, err := strconv.ParseUint(limit, 10, 64)
+			return v, err == nil
+		}
+	}
+	if dir, err := c.ProcessCgroupPath(pid, ""); err == nil {
+		limit, err := root.ReadString(path.Join(dir, "memory.max"))
+		if err != nil || limit == "max" {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(limit, 10, 64)
+		return v, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,152 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func memoryFixture() fstest.MapFS {
+	return fstest.MapFS{
+		"proc/meminfo":  {Data: []byte("MemTotal:       16777216 kB\nMemFree:         1024 kB\n")},
+		"proc/42/statm": {Data: []byte("1000 250 100 10 0 300 0\n")},
+		"proc/42/status": {Data: []byte("Name:\tbash\n" +
+			"VmRSS:\t    1000 kB\n" +
+			"RssAnon:\t     600 kB\n" +
+			"RssFile:\t     300 kB\n" +
+			"RssShmem:\t     100 kB\n" +
+			"VmSwap:\t       8 kB\n")},
+		// two mappings, as before Linux 4.14 without smaps_rollup
+		"proc/42/smaps": {Data: []byte("00400000-0040b000 r-xp 00000000 fd:01 1234 /usr/bin/bash\n" +
+			"Size:                 44 kB\n" +
+			"Rss:                  40 kB\n" +
+			"Pss:                  20 kB\n" +
+			"Shared_Clean:         32 kB\n" +
+			"Shared_Dirty:          0 kB\n" +
+			"Private_Clean:         8 kB\n" +
+			"Private_Dirty:         0 kB\n" +
+			"Swap:                  0 kB\n" +
+			"SwapPss:               0 kB\n" +
+			"VmFlags: rd ex mr mw me dw\n" +
+			"7ffd5000-7fff6000 rw-p 00000000 00:00 0 [stack]\n" +
+			"Size:                132 kB\n" +
+			"Rss:                  16 kB\n" +
+			"Pss:                  16 kB\n" +
+			"Shared_Clean:          0 kB\n" +
+			"Shared_Dirty:          0 kB\n" +
+			"Private_Clean:         0 kB\n" +
+			"Private_Dirty:        16 kB\n" +
+			"Swap:                  4 kB\n" +
+			"SwapPss:               4 kB\n" +
+			"VmFlags: rd wr mr mw me gd ac\n")},
+		"proc/42/cgroup":                   {Data: []byte("0::/app\n")},
+		"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory\n")},
+		"sys/fs/cgroup/app/memory.max":     {Data: []byte("1073741824\n")},
+	}
+}
+
+func TestMemoryInfoFixture(t *testing.T) {
+	root := cpu.NewRoot(memoryFixture())
+	p := &Process{Pid: 42, root: &root, boot: &bootTime{}}
+	ctx := context.Background()
+
+	mem, err := p.MemoryInfoWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := uint64(os.Getpagesize())
+	want := MemoryInfoStat{
+		VMS: 1000 * page, RSS: 250 * page, Shared: 100 * page, Text: 10 * page, Data: 300 * page,
+		Swap: 8 << 10, RSSAnon: 600 << 10, RSSFile: 300 << 10, RSSShmem: 100 << 10,
+	}
+	if *mem != want {
+		t.Errorf("MemoryInfo = %+v, want %+v", *mem, want)
+	}
+
+	ex, err := p.MemoryInfoExWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEx := MemoryInfoExStat{
+		RSS: 56 << 10, PSS: 36 << 10, USS: 24 << 10,
+		SharedClean: 32 << 10, PrivateClean: 8 << 10, PrivateDirty: 16 << 10,
+		Swap: 4 << 10, SwapPSS: 4 << 10,
+	}
+	if *ex != wantEx {
+		t.Errorf("MemoryInfoEx = %+v, want %+v", *ex, wantEx)
+	}
+
+	// RSS against the cgroup limit of 1 GiB rather than the 16 GiB of RAM
+	percent, err := p.MemoryPercentWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantPercent := float64(250*page) / (1 << 30) * 100; percent != wantPercent {
+		t.Errorf("MemoryPercent = %v, want %v", percent, wantPercent)
+	}
+}
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	cases := []struct {
+		name   string
+		files  fstest.MapFS
+		want   uint64
+		wantOK bool
+	}{
+		{
+			name: "v2",
+			files: fstest.MapFS{
+				"proc/42/cgroup":                   {Data: []byte("0::/app\n")},
+				"sys/fs/cgroup/cgroup.controllers": {Data: []byte("memory\n")},
+				"sys/fs/cgroup/app/memory.max":     {Data: []byte("536870912\n")},
+			},
+			want: 512 << 20, wantOK: true,
+		},
+		{
+			name: "v2 unlimited",
+			files: fstest.MapFS{
+				"proc/42/cgroup":                   {Data: []byte("0::/app\n")},
+				"sys/fs/cgroup/cgroup.controllers": {Data: []byte("memory\n")},
+				"sys/fs/cgroup/app/memory.max":     {Data: []byte("max\n")},
+			},
+		},
+		{
+			name: "v1",
+			files: fstest.MapFS{
+				"proc/42/cgroup": {Data: []byte("4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n")},
+				"sys/fs/cgroup/memory/docker/abc/memory.limit_in_bytes": {Data: []byte("268435456\n")},
+			},
+			want: 256 << 20, wantOK: true,
+		},
+		{
+			name:  "no cgroup",
+			files: fstest.MapFS{"proc/42/cgroup": {Data: []byte("0::/\n")}},
+		},
+	}
+	for _, c := range cases {
+		limit, ok := cgroupMemoryLimit(cpu.NewRoot(c.files), 42)
+		if limit != c.want || ok != c.wantOK {
+			t.Errorf("%s: cgroupMemoryLimit = %d, %v, want %d, %v", c.name, limit, ok, c.want, c.wantOK)
+		}
+	}
+}
+
+func TestParseKBLines(t *testing.T) {
+	var rss, swap uint64
+	fields := map[string]*uint64{"Rss": &rss, "Swap": &swap}
+	lines := []string{"Rss:  4 kB", "Pss:  2 kB", "no colon", "Rss:  8 kB", "Swap: 0 kB"}
+	if err := parseKBLines(lines, fields); err != nil {
+		t.Fatal(err)
+	}
+	if rss != 12<<10 || swap != 0 {
+		t.Errorf("parseKBLines: Rss = %d, Swap = %d, want %d, 0", rss, swap, 12<<10)
+	}
+	if err := parseKBLines([]string{"Rss: many kB"}, fields); err == nil {
+		t.Error("parseKBLines of a bad value must fail")
+	}
+}
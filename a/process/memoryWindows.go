@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package process
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+)
+
+func (p *Process) memoryInfoWithContext(ctx context.Context) (*MemoryInfoStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) memoryInfoExWithContext(ctx context.Context) (*MemoryInfoExStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) memoryLimitWithContext(ctx context.Context) (uint64, error) {
+	return 0, cpu.ErrorNotImplemented
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"cpuV3/a/cpu"
 	"errors"
+	"io/fs"
 	"runtime"
 	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -18,8 +22,55 @@ type Process struct {
 	createTime   int64
 	lastCPUTimes *cpu.TimesStat
 	lastCPUTime  time.Time
+	lastFaults   cpu.Baseline[*PageFaultsStat]
+
+	root   *cpu.Root
+	clocks float64
+	boot   *bootTime
+}
+
+// bootTime caches the boot time, which create times are relative to.
+// Processes of one enumeration share it.
+type bootTime struct {
+	once  sync.Once
+	value uint64
+	err   error
+}
+
+type Option func(*Process)
+
+// WithRoot makes the process read procfs through root instead of
+// cpu.DefaultRoot.
+func WithRoot(root cpu.Root) Option {
+	return func(p *Process) {
+		p.root = &root
+	}
 }
 
+// WithClocksPerSec overrides cpu.ClocksPerSec for this process, for parsing
+// files captured on a host with a different USER_HZ.
+func WithClocksPerSec(clocks float64) Option {
+	return func(p *Process) {
+		p.clocks = clocks
+	}
+}
+
+// Status is the scheduling state of a process.
+type Status string
+
+const (
+	Running     Status = "running"
+	Sleeping    Status = "sleeping"
+	DiskSleep   Status = "disk-sleep"
+	Stopped     Status = "stopped"
+	TracingStop Status = "tracing-stop"
+	Zombie      Status = "zombie"
+	Dead        Status = "dead"
+	Idle        Status = "idle"
+	Parked      Status = "parked"
+	Unknown     Status = "unknown"
+)
+
 type PageFaultsStat struct {
 	MinorFaults      uint64 `json:"minorFaults"`
 	MajorFaults      uint64 `json:"majorFaults"`
@@ -27,16 +78,79 @@ type PageFaultsStat struct {
 	ChildMajorFaults uint64 `json:"childMajorFaults"`
 }
 
-func NewProcess(pid int32) (*Process, error) {
-	return newProcessWithContext(context.Background(), pid)
+// PageFaultRateStat holds the page faults per second between two samples.
+// Major faults needed disk I/O, minor faults didn't.
+type PageFaultRateStat struct {
+	MinorPerSec      float64 `json:"minorPerSec"`
+	MajorPerSec      float64 `json:"majorPerSec"`
+	ChildMinorPerSec float64 `json:"childMinorPerSec"`
+	ChildMajorPerSec float64 `json:"childMajorPerSec"`
+	IntervalSeconds  float64 `json:"intervalSeconds"`
+}
+
+// ProcStat is the content of /proc/[pid]/stat, see proc(5). Times are in
+// clock ticks, see cpu.ClocksPerSec.
+type ProcStat struct {
+	Pid  int32  `json:"pid"`
+	Comm string `json:"comm"`
+	// State is one character, such as "R" or "S".
+	State   string `json:"state"`
+	Ppid    int32  `json:"ppid"`
+	Pgrp    int32  `json:"pgrp"`
+	Session int32  `json:"session"`
+	// TtyNr is the controlling terminal as a device number, 0 if none.
+	TtyNr   int32  `json:"ttyNr"`
+	Flags   uint32 `json:"flags"`
+	MinFlt  uint64 `json:"minflt"`
+	CMinFlt uint64 `json:"cminflt"`
+	MajFlt  uint64 `json:"majflt"`
+	CMajFlt uint64 `json:"cmajflt"`
+	Utime   uint64 `json:"utime"`
+	Stime   uint64 `json:"stime"`
+	// Cutime and Cstime are the times of waited-for children.
+	Cutime     int64 `json:"cutime"`
+	Cstime     int64 `json:"cstime"`
+	Priority   int64 `json:"priority"`
+	Nice       int64 `json:"nice"`
+	NumThreads int64 `json:"numThreads"`
+	// Starttime is the start of the process in ticks after boot.
+	Starttime uint64 `json:"starttime"`
+	// Vsize is the virtual memory size in bytes, Rss the resident set in
+	// pages.
+	Vsize uint64 `json:"vsize"`
+	Rss   int64  `json:"rss"`
+	// Processor is the CPU the process last ran on.
+	Processor           int32  `json:"processor"`
+	RtPriority          uint32 `json:"rtPriority"`
+	Policy              uint32 `json:"policy"`
+	DelayacctBlkioTicks uint64 `json:"delayacctBlkioTicks"`
 }
 
-func newProcessWithContext(ctx context.Context, pid int32) (*Process, error) {
+// PageFaults returns the fault counters of the stat, the child counters
+// covering waited-for children.
+func (s *ProcStat) PageFaults() *PageFaultsStat {
+	return &PageFaultsStat{
+		MinorFaults:      s.MinFlt,
+		MajorFaults:      s.MajFlt,
+		ChildMinorFaults: s.CMinFlt,
+		ChildMajorFaults: s.CMajFlt,
+	}
+}
+
+func NewProcess(pid int32, opts ...Option) (*Process, error) {
+	return newProcessWithContext(context.Background(), pid, opts...)
+}
+
+func newProcessWithContext(ctx context.Context, pid int32, opts ...Option) (*Process, error) {
 	p := &Process{
-		Pid: pid,
+		Pid:  pid,
+		boot: &bootTime{},
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
 
-	exists, err := pidExistsWithContext(ctx, pid)
+	exists, err := pidExistsWithContext(ctx, p.hostRoot(), pid)
 	if err != nil {
 		return p, err
 	}
@@ -47,8 +161,81 @@ func newProcessWithContext(ctx context.Context, pid int32) (*Process, error) {
 	return p, nil
 }
 
-func pidsWithContext(ctx context.Context) ([]int32, error) {
-	pids, err := pidsWithCtx(ctx)
+func (p *Process) hostRoot() cpu.Root {
+	if p.root == nil {
+		return cpu.DefaultRoot()
+	}
+	return *p.root
+}
+
+func Pids() ([]int32, error) {
+	return PidsWithContext(context.Background())
+}
+
+// PidsWithContext returns the PIDs of all processes, sorted.
+func PidsWithContext(ctx context.Context) ([]int32, error) {
+	return pidsWithContext(ctx, cpu.DefaultRoot())
+}
+
+func PidsWithRoot(ctx context.Context, root cpu.Root) ([]int32, error) {
+	return pidsWithContext(ctx, root)
+}
+
+func Processes(filters ...Filter) ([]*Process, error) {
+	return ProcessesWithContext(context.Background(), filters...)
+}
+
+// ProcessesWithContext returns the processes that pass all filters, ordered
+// by PID. Processes that exit during the enumeration are left out, zombies
+// are included.
+func ProcessesWithContext(ctx context.Context, filters ...Filter) ([]*Process, error) {
+	return processesWithContext(ctx, cpu.DefaultRoot(), nil, filters)
+}
+
+func ProcessesWithRoot(ctx context.Context, root cpu.Root, filters ...Filter) ([]*Process, error) {
+	return processesWithContext(ctx, root, []Option{WithRoot(root)}, filters)
+}
+
+// processesWithContext doesn't check that the processes are running and
+// leaves the create time to be read on demand, so zombies are listed and
+// only the filters read from /proc.
+func processesWithContext(ctx context.Context, root cpu.Root, opts []Option, filters []Filter) ([]*Process, error) {
+	pids, err := pidsWithContext(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	boot := &bootTime{}
+	ret := make([]*Process, 0, len(pids))
+	for _, pid := range pids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p := &Process{Pid: pid, boot: boot}
+		for _, opt := range opts {
+			opt(p)
+		}
+		ok, err := matchAll(ctx, p, filters)
+		if err != nil {
+			if vanished(err) {
+				continue
+			}
+			return nil, err
+		}
+		if ok {
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
+
+// vanished reports whether err is caused by the process having exited.
+func vanished(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ESRCH)
+}
+
+func pidsWithContext(ctx context.Context, root cpu.Root) ([]int32, error) {
+	pids, err := pidsWithCtx(ctx, root)
 	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
 	return pids, err
 }
@@ -62,9 +249,109 @@ func (p *Process) CreateTimeWithContext(ctx context.Context) (int64, error) {
 	return p.createTime, err
 }
 
+// Name returns the name of the executable, without the path.
+func (p *Process) Name() (string, error) {
+	return p.NameWithContext(context.Background())
+}
+
+func (p *Process) NameWithContext(ctx context.Context) (string, error) {
+	return p.nameWithContext(ctx)
+}
+
+// Cmdline returns the command line with the arguments joined by spaces.
+func (p *Process) Cmdline() (string, error) {
+	return p.CmdlineWithContext(context.Background())
+}
+
+func (p *Process) CmdlineWithContext(ctx context.Context) (string, error) {
+	args, err := p.cmdlineSliceWithContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(args, " "), nil
+}
+
+// CmdlineSlice returns the command line arguments, the first being the
+// program. It is empty for kernel threads.
+func (p *Process) CmdlineSlice() ([]string, error) {
+	return p.CmdlineSliceWithContext(context.Background())
+}
+
+func (p *Process) CmdlineSliceWithContext(ctx context.Context) ([]string, error) {
+	return p.cmdlineSliceWithContext(ctx)
+}
+
+// Exe returns the path of the executable.
+func (p *Process) Exe() (string, error) {
+	return p.ExeWithContext(context.Background())
+}
+
+func (p *Process) ExeWithContext(ctx context.Context) (string, error) {
+	return p.exeWithContext(ctx)
+}
+
+// Cwd returns the working directory.
+func (p *Process) Cwd() (string, error) {
+	return p.CwdWithContext(context.Background())
+}
+
+func (p *Process) CwdWithContext(ctx context.Context) (string, error) {
+	return p.cwdWithContext(ctx)
+}
+
+func (p *Process) Status() (Status, error) {
+	return p.StatusWithContext(context.Background())
+}
+
+func (p *Process) StatusWithContext(ctx context.Context) (Status, error) {
+	return p.statusWithContext(ctx)
+}
+
+func (p *Process) Ppid() (int32, error) {
+	return p.PpidWithContext(context.Background())
+}
+
+func (p *Process) PpidWithContext(ctx context.Context) (int32, error) {
+	return p.ppidWithContext(ctx)
+}
+
+// Uids returns the real, effective, saved set and filesystem UIDs.
+func (p *Process) Uids() ([]int32, error) {
+	return p.UidsWithContext(context.Background())
+}
+
+func (p *Process) UidsWithContext(ctx context.Context) ([]int32, error) {
+	return p.uidsWithContext(ctx)
+}
+
+// Gids returns the real, effective, saved set and filesystem GIDs.
+func (p *Process) Gids() ([]int32, error) {
+	return p.GidsWithContext(context.Background())
+}
+
+func (p *Process) GidsWithContext(ctx context.Context) ([]int32, error) {
+	return p.gidsWithContext(ctx)
+}
+
+// Username returns the name of the real user of the process.
+func (p *Process) Username() (string, error) {
+	return p.UsernameWithContext(context.Background())
+}
+
+func (p *Process) UsernameWithContext(ctx context.Context) (string, error) {
+	return p.usernameWithContext(ctx)
+}
+
+// PercentWithContext uses the time left until the deadline of ctx as the
+// sampling interval, see PercentInterval.
 func (p *Process) PercentWithContext(ctx context.Context) (float64, error) {
-	interval := cpu.GetTimeoutDuration(ctx)
+	return p.PercentInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
 
+// PercentInterval returns the CPU usage of the process over interval, where
+// 100 means one fully used CPU. ctx is only used for cancellation. If
+// interval is 0 or less the value is relative to the previous call.
+func (p *Process) PercentInterval(ctx context.Context, interval time.Duration) (float64, error) {
 	cpuTimes, err := p.timesWithContext(ctx)
 	if err != nil {
 		return 0, err
@@ -99,6 +386,71 @@ func (p *Process) PercentWithContext(ctx context.Context) (float64, error) {
 	return ret, nil
 }
 
+// PageFaultRateWithContext uses the time left until the deadline of ctx as
+// the sampling interval, see PageFaultRateInterval.
+func (p *Process) PageFaultRateWithContext(ctx context.Context) (*PageFaultRateStat, error) {
+	return p.PageFaultRateInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
+
+// PageFaultRateInterval reads the page fault counters twice, interval apart,
+// and returns the faults per second in between. ctx is only used for
+// cancellation. If interval is 0 or less the rates are relative to the
+// previous call, and all 0 on the first one.
+func (p *Process) PageFaultRateInterval(ctx context.Context, interval time.Duration) (*PageFaultRateStat, error) {
+	f1, f2, err := cpu.SampleInterval(ctx, interval, &p.lastFaults, func() (*PageFaultsStat, error) {
+		return p.PageFaults(ctx)
+	})
+	if errors.Is(err, cpu.ErrorNoBaseline) {
+		// invoked first time
+		return &PageFaultRateStat{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return calculatePageFaultRate(f1.Value, f2.Value, f2.At.Sub(f1.At).Seconds())
+}
+
+func calculatePageFaultRate(f1, f2 *PageFaultsStat, elapsed float64) (*PageFaultRateStat, error) {
+	if f2.MinorFaults < f1.MinorFaults || f2.MajorFaults < f1.MajorFaults ||
+		f2.ChildMinorFaults < f1.ChildMinorFaults || f2.ChildMajorFaults < f1.ChildMajorFaults {
+		return nil, cpu.ErrorCounterDecreased
+	}
+	ret := &PageFaultRateStat{IntervalSeconds: elapsed}
+	if elapsed <= 0 {
+		return ret, nil
+	}
+	ret.MinorPerSec = float64(f2.MinorFaults-f1.MinorFaults) / elapsed
+	ret.MajorPerSec = float64(f2.MajorFaults-f1.MajorFaults) / elapsed
+	ret.ChildMinorPerSec = float64(f2.ChildMinorFaults-f1.ChildMinorFaults) / elapsed
+	ret.ChildMajorPerSec = float64(f2.ChildMajorFaults-f1.ChildMajorFaults) / elapsed
+	return ret, nil
+}
+
+// NormalizedPercentWithContext uses the time left until the deadline of ctx
+// as the sampling interval, see NormalizedPercentInterval.
+func (p *Process) NormalizedPercentWithContext(ctx context.Context) (float64, error) {
+	return p.NormalizedPercentInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
+
+// NormalizedPercentInterval is PercentInterval divided by the number of CPUs
+// the process can actually use, honoring its cgroup quota, cpuset and
+// affinity mask. 100 means all of them are busy.
+func (p *Process) NormalizedPercentInterval(ctx context.Context, interval time.Duration) (float64, error) {
+	percent, err := p.PercentInterval(ctx, interval)
+	if err != nil {
+		return 0, err
+	}
+	counts, err := cpu.NewCollector(cpu.WithRoot(p.hostRoot())).ProcessEffectiveCountsWithContext(ctx, p.Pid)
+	if err != nil {
+		return 0, err
+	}
+	effective := counts.Effective()
+	if effective == 0 {
+		return 0, nil
+	}
+	return percent / effective, nil
+}
+
 func calculatePercent(t1, t2 *cpu.TimesStat, delta float64, numcpu int) float64 {
 	if delta == 0 {
 		return 0
@@ -7,35 +7,41 @@ import (
 	"bytes"
 	"context"
 	"cpuV3/a/cpu"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 )
 
-var ClockTicks = 100
+// ClockTicks is cpu.ClocksPerSec as resolved at startup. It is no longer read
+// by this package, setting it has no effect.
+//
+// Deprecated: use cpu.ClocksPerSec or WithClocksPerSec.
+var ClockTicks = int(cpu.ClocksPerSec)
 
-func pidsWithCtx(ctx context.Context) ([]int32, error) {
-	return readPidsFromDir(cpu.HostProc())
+func (p *Process) clocksPerSec() float64 {
+	if p.clocks > 0 {
+		return p.clocks
+	}
+	return cpu.ClocksPerSec
 }
 
-func readPidsFromDir(path string) ([]int32, error) {
-	var ret []int32
+func pidsWithCtx(ctx context.Context, root cpu.Root) ([]int32, error) {
+	return readPidsFromDir(root, root.ProcPath())
+}
 
-	d, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer d.Close()
+func readPidsFromDir(root cpu.Root, path string) ([]int32, error) {
+	var ret []int32
 
-	fnames, err := d.Readdirnames(-1)
+	entries, err := root.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
-	for _, fname := range fnames {
-		pid, err := strconv.ParseInt(fname, 10, 32)
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
 		if err != nil {
 			// if not numeric name, just skip
 			continue
@@ -47,108 +53,323 @@ func readPidsFromDir(path string) ([]int32, error) {
 }
 
 func (p *Process) timesWithContext(ctx context.Context) (*cpu.TimesStat, error) {
-	_, _, cpuTimes, _, _, _, _, err := p.fillFromStatWithContext(ctx)
+	stat, err := p.Stat(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return cpuTimes, nil
+	return &cpu.TimesStat{
+		CPU:    "cpu",
+		User:   float64(stat.Utime) / p.clocksPerSec(),
+		System: float64(stat.Stime) / p.clocksPerSec(),
+		Iowait: float64(stat.DelayacctBlkioTicks) / p.clocksPerSec(),
+	}, nil
 }
 
-func (p *Process) fillFromStatWithContext(ctx context.Context) (uint64, int32, *cpu.TimesStat, int64, uint32, int32, *PageFaultsStat, error) {
-	return p.fillFromTIDStatWithContext(ctx, -1)
+// nameWithContext reads /proc/[pid]/comm. The kernel truncates it to 15
+// bytes, so a longer name is taken from the command line if it starts the
+// same.
+func (p *Process) nameWithContext(ctx context.Context) (string, error) {
+	root := p.hostRoot()
+	name, err := root.ReadString(root.ProcPath(strconv.Itoa(int(p.Pid)), "comm"))
+	if err != nil {
+		return "", err
+	}
+	if len(name) < 15 {
+		return name, nil
+	}
+	args, err := p.cmdlineSliceWithContext(ctx)
+	if err != nil || len(args) == 0 {
+		return name, nil
+	}
+	if exe := path.Base(args[0]); strings.HasPrefix(exe, name) {
+		return exe, nil
+	}
+	return name, nil
 }
 
-func (p *Process) fillFromTIDStatWithContext(ctx context.Context, tid int32) (uint64, int32, *cpu.TimesStat, int64, uint32, int32, *PageFaultsStat, error) {
-	pid := p.Pid
-	var statPath string
-
-	if tid == -1 {
-		statPath = cpu.HostProc(strconv.Itoa(int(pid)), "stat")
-	} else {
-		statPath = cpu.HostProc(strconv.Itoa(int(pid)), "task", strconv.Itoa(int(tid)), "stat")
+func (p *Process) cmdlineSliceWithContext(ctx context.Context) ([]string, error) {
+	root := p.hostRoot()
+	cmdline, err := root.ReadFile(root.ProcPath(strconv.Itoa(int(p.Pid)), "cmdline"))
+	if err != nil {
+		return nil, err
 	}
+	// kernel threads have an empty command line
+	if len(cmdline) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00"), nil
+}
 
-	contents, err := ioutil.ReadFile(statPath)
+func (p *Process) exeWithContext(ctx context.Context) (string, error) {
+	root := p.hostRoot()
+	return root.ReadLink(root.ProcPath(strconv.Itoa(int(p.Pid)), "exe"))
+}
+
+func (p *Process) cwdWithContext(ctx context.Context) (string, error) {
+	root := p.hostRoot()
+	return root.ReadLink(root.ProcPath(strconv.Itoa(int(p.Pid)), "cwd"))
+}
+
+func (p *Process) statusWithContext(ctx context.Context) (Status, error) {
+	stat, err := p.Stat(ctx)
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return Unknown, err
 	}
-	// Indexing from one, as described in `man proc` about the file /proc/[pid]/stat
-	fields := splitProcStat(contents)
+	return convertStatusChar(stat.State), nil
+}
+
+// convertStatusChar maps the state letter of /proc/[pid]/stat, see proc(5).
+func convertStatusChar(letter string) Status {
+	switch letter {
+	case "R":
+		return Running
+	case "S":
+		return Sleeping
+	case "D":
+		return DiskSleep
+	case "T":
+		return Stopped
+	case "t":
+		return TracingStop
+	case "Z":
+		return Zombie
+	case "X", "x":
+		return Dead
+	case "I":
+		return Idle
+	case "P":
+		return Parked
+	default:
+		return Unknown
+	}
+}
+
+func (p *Process) uidsWithContext(ctx context.Context) ([]int32, error) {
+	return p.statusIDs("Uid:")
+}
 
-	terminal, err := strconv.ParseUint(fields[7], 10, 64)
+func (p *Process) gidsWithContext(ctx context.Context) ([]int32, error) {
+	return p.statusIDs("Gid:")
+}
+
+// statusIDs parses the Uid: or Gid: line of /proc/[pid]/status.
+func (p *Process) statusIDs(key string) ([]int32, error) {
+	root := p.hostRoot()
+	lines, err := root.ReadLines(root.ProcPath(strconv.Itoa(int(p.Pid)), "status"))
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return nil, err
 	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		var ids []int32
+		for _, field := range strings.Fields(strings.TrimPrefix(line, key)) {
+			id, err := strconv.ParseInt(field, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, int32(id))
+		}
+		return ids, nil
+	}
+	return nil, fmt.Errorf("could not find %s in status of pid %d", key, p.Pid)
+}
 
-	ppid, err := strconv.ParseInt(fields[4], 10, 32)
+// usernameWithContext looks the real UID up in the passwd file of the root,
+// so that it names the user of the host when reading a mounted host /proc.
+// A UID without an entry is returned as a number, like ps does.
+func (p *Process) usernameWithContext(ctx context.Context) (string, error) {
+	uids, err := p.uidsWithContext(ctx)
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return "", err
+	}
+	if len(uids) == 0 {
+		return "", fmt.Errorf("no uid in status of pid %d", p.Pid)
+	}
+	uid := strconv.Itoa(int(uids[0]))
+
+	root := p.hostRoot()
+	lines, err := root.ReadLines(root.EtcPath("passwd"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	for _, line := range lines {
+		// name:password:UID:GID:GECOS:directory:shell
+		fields := strings.Split(line, ":")
+		if len(fields) > 2 && fields[2] == uid {
+			return fields[0], nil
+		}
 	}
-	utime, err := strconv.ParseFloat(fields[14], 64)
+	return uid, nil
+}
+
+func (p *Process) ppidWithContext(ctx context.Context) (int32, error) {
+	stat, err := p.Stat(ctx)
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return 0, err
 	}
+	return stat.Ppid, nil
+}
+
+func (p *Process) Stat(ctx context.Context) (*ProcStat, error) {
+	return p.statWithContext(ctx, -1)
+}
 
-	stime, err := strconv.ParseFloat(fields[15], 64)
+// PageFaults returns the page fault counters since the process started.
+func (p *Process) PageFaults(ctx context.Context) (*PageFaultsStat, error) {
+	stat, err := p.Stat(ctx)
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return nil, err
 	}
+	return stat.PageFaults(), nil
+}
 
-	var iotime float64
-	if len(fields) > 42 {
-		iotime, err = strconv.ParseFloat(fields[42], 64)
-		if err != nil {
-			iotime = 0
-		}
+// statWithContext reads /proc/[pid]/stat, or the stat of thread tid if it
+// isn't -1.
+func (p *Process) statWithContext(ctx context.Context, tid int32) (*ProcStat, error) {
+	pid := p.Pid
+	root := p.hostRoot()
+	var statPath string
+
+	if tid == -1 {
+		statPath = root.ProcPath(strconv.Itoa(int(pid)), "stat")
 	} else {
-		iotime = 0
+		statPath = root.ProcPath(strconv.Itoa(int(pid)), "task", strconv.Itoa(int(tid)), "stat")
 	}
 
-	cpuTimes := &cpu.TimesStat{
-		CPU:    "cpu",
-		User:   utime / float64(ClockTicks),
-		System: stime / float64(ClockTicks),
-		Iowait: iotime / float64(ClockTicks),
+	contents, err := root.ReadFile(statPath)
+	if err != nil {
+		return nil, err
 	}
+	return parseProcStat(contents)
+}
 
-	bootTime, _ := BootTimeWithContext(ctx)
-	t, err := strconv.ParseUint(fields[22], 10, 64)
+// parseProcStat parses the content of a stat file. Fields after rss were
+// added over time and are left 0 when missing.
+func parseProcStat(content []byte) (*ProcStat, error) {
+	// Indexing from one, as described in `man proc` about the file /proc/[pid]/stat
+	fields, err := splitProcStat(content)
 	if err != nil {
-		return 0, 0, nil, 0, 0, 0, nil, err
+		return nil, err
+	}
+	if len(fields) <= 24 {
+		return nil, fmt.Errorf("too few fields in stat: %d", len(fields)-1)
 	}
-	ctime := (t / uint64(ClockTicks)) + uint64(bootTime)
-	createTime := int64(ctime * 1000)
 
-	return terminal, int32(ppid), cpuTimes, createTime, 0, 0, nil, nil
+	parseInt := func(i, bits int) int64 {
+		if err != nil || i >= len(fields) {
+			return 0
+		}
+		var v int64
+		if v, err = strconv.ParseInt(fields[i], 10, bits); err != nil {
+			err = fmt.Errorf("field %d of stat: %w", i, err)
+		}
+		return v
+	}
+	parseUint := func(i, bits int) uint64 {
+		if err != nil || i >= len(fields) {
+			return 0
+		}
+		var v uint64
+		if v, err = strconv.ParseUint(fields[i], 10, bits); err != nil {
+			err = fmt.Errorf("field %d of stat: %w", i, err)
+		}
+		return v
+	}
+
+	ret := &ProcStat{
+		Pid:                 int32(parseInt(1, 32)),
+		Comm:                fields[2],
+		State:               fields[3],
+		Ppid:                int32(parseInt(4, 32)),
+		Pgrp:                int32(parseInt(5, 32)),
+		Session:             int32(parseInt(6, 32)),
+		TtyNr:               int32(parseInt(7, 32)),
+		Flags:               uint32(parseUint(9, 32)),
+		MinFlt:              parseUint(10, 64),
+		CMinFlt:             parseUint(11, 64),
+		MajFlt:              parseUint(12, 64),
+		CMajFlt:             parseUint(13, 64),
+		Utime:               parseUint(14, 64),
+		Stime:               parseUint(15, 64),
+		Cutime:              parseInt(16, 64),
+		Cstime:              parseInt(17, 64),
+		Priority:            parseInt(18, 64),
+		Nice:                parseInt(19, 64),
+		NumThreads:          parseInt(20, 64),
+		Starttime:           parseUint(22, 64),
+		Vsize:               parseUint(23, 64),
+		Rss:                 parseInt(24, 64),
+		Processor:           int32(parseInt(39, 32)),
+		RtPriority:          uint32(parseUint(40, 32)),
+		Policy:              uint32(parseUint(41, 32)),
+		DelayacctBlkioTicks: parseUint(42, 64),
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
-func splitProcStat(content []byte) []string {
+// splitProcStat splits a stat file into its fields, with the command name,
+// which may contain spaces and parentheses, as one field.
+func splitProcStat(content []byte) ([]string, error) {
 	nameStart := bytes.IndexByte(content, '(')
 	nameEnd := bytes.LastIndexByte(content, ')')
-	restFields := strings.Fields(string(content[nameEnd+2:])) // +2 skip ') '
+	if nameStart < 0 || nameEnd < nameStart {
+		return nil, fmt.Errorf("could not find the command name in stat")
+	}
+	restFields := strings.Fields(string(content[nameEnd+1:]))
 	name := content[nameStart+1 : nameEnd]
 	pid := strings.TrimSpace(string(content[:nameStart]))
 	fields := make([]string, 3, len(restFields)+3)
 	fields[1] = string(pid)
 	fields[2] = string(name)
 	fields = append(fields, restFields...)
-	return fields
+	return fields, nil
 }
 
 func (p *Process) createTimeWithContext(ctx context.Context) (int64, error) {
-	_, _, _, createTime, _, _, _, err := p.fillFromStatWithContext(ctx)
+	stat, err := p.Stat(ctx)
 	if err != nil {
 		return 0, err
 	}
-	return createTime, nil
+	bootTime, _ := p.bootTimeWithContext(ctx)
+	ctime := (stat.Starttime / uint64(p.clocksPerSec())) + bootTime
+	return int64(ctime * 1000), nil
 }
 
 func BootTimeWithContext(ctx context.Context) (uint64, error) {
-	system, role, err := Virtualization()
+	system, role, err := VirtualizationWithContext(ctx)
 	if err != nil {
 		return 0, err
 	}
+	return bootTimeWithRoot(cpu.DefaultRoot(), system, role)
+}
+
+// bootTimeWithContext reads the boot time once per process, or once per
+// enumeration for processes returned by Processes.
+func (p *Process) bootTimeWithContext(ctx context.Context) (uint64, error) {
+	if p.boot == nil {
+		p.boot = &bootTime{}
+	}
+	p.boot.once.Do(func() {
+		if p.root == nil {
+			p.boot.value, p.boot.err = BootTimeWithContext(ctx)
+			return
+		}
+		system, role, err := virtualizationWithRoot(*p.root)
+		if err != nil {
+			p.boot.err = err
+			return
+		}
+		p.boot.value, p.boot.err = bootTimeWithRoot(*p.root, system, role)
+	})
+	return p.boot.value, p.boot.err
+}
 
+func bootTimeWithRoot(root cpu.Root, system, role string) (uint64, error) {
 	statFile := "stat"
 	if system == "lxc" && role == "guest" {
 		// if lxc, /proc/uptime is used.
@@ -158,8 +379,8 @@ func BootTimeWithContext(ctx context.Context) (uint64, error) {
 		statFile = "uptime"
 	}
 
-	filename := cpu.HostProc(statFile)
-	lines, err := cpu.ReadLines(filename)
+	filename := root.ProcPath(statFile)
+	lines, err := root.ReadLines(filename)
 	if err != nil {
 		return 0, err
 	}
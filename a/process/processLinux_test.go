@@ -0,0 +1,105 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// statLine builds a /proc/[pid]/stat line with the given command name and
+// the fields from state on, numbered as in `man proc`, filled with their own
+// number up to field n.
+func statLine(comm string, n int) string {
+	fields := []string{"42", "(" + comm + ")", "S"}
+	for i := 4; i <= n; i++ {
+		fields = append(fields, fmt.Sprint(i))
+	}
+	return strings.Join(fields, " ") + "\n"
+}
+
+func TestSplitProcStat(t *testing.T) {
+	cases := []struct {
+		content string
+		pid     string
+		comm    string
+		n       int
+		wantErr bool
+	}{
+		{content: statLine("bash", 52), pid: "42", comm: "bash", n: 53},
+		{content: statLine("a b", 24), pid: "42", comm: "a b", n: 25},
+		{content: statLine("a) (b", 24), pid: "42", comm: "a) (b", n: 25},
+		{content: statLine(")", 24), pid: "42", comm: ")", n: 25},
+		{content: "42 (bash) S", pid: "42", comm: "bash", n: 4},
+		{content: "", wantErr: true},
+		{content: "42 (bash S 1 2 3", wantErr: true},
+		{content: "42 bash) S 1 2 3", wantErr: true},
+	}
+	for _, c := range cases {
+		fields, err := splitProcStat([]byte(c.content))
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("splitProcStat(%q) = %q, want error", c.content, fields)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitProcStat(%q): %v", c.content, err)
+			continue
+		}
+		if len(fields) != c.n || fields[1] != c.pid || fields[2] != c.comm {
+			t.Errorf("splitProcStat(%q) = %q", c.content, fields)
+		}
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    ProcStat
+		wantErr bool
+	}{
+		{
+			name:    "current",
+			content: statLine("a) (b", 52),
+			want: ProcStat{Pid: 42, Comm: "a) (b", State: "S", Ppid: 4, Pgrp: 5, Session: 6, TtyNr: 7,
+				Flags: 9, MinFlt: 10, CMinFlt: 11, MajFlt: 12, CMajFlt: 13, Utime: 14, Stime: 15,
+				Cutime: 16, Cstime: 17, Priority: 18, Nice: 19, NumThreads: 20, Starttime: 22,
+				Vsize: 23, Rss: 24, Processor: 39, RtPriority: 40, Policy: 41, DelayacctBlkioTicks: 42},
+		},
+		{
+			// kernels before 2.6 end the line before the processor field
+			name:    "pre-2.6",
+			content: statLine("init", 37),
+			want: ProcStat{Pid: 42, Comm: "init", State: "S", Ppid: 4, Pgrp: 5, Session: 6, TtyNr: 7,
+				Flags: 9, MinFlt: 10, CMinFlt: 11, MajFlt: 12, CMajFlt: 13, Utime: 14, Stime: 15,
+				Cutime: 16, Cstime: 17, Priority: 18, Nice: 19, NumThreads: 20, Starttime: 22,
+				Vsize: 23, Rss: 24},
+		},
+		{name: "empty", content: "", wantErr: true},
+		{name: "truncated", content: statLine("bash", 23), wantErr: true},
+		{name: "truncated in comm", content: "42 (bash", wantErr: true},
+		{name: "missing paren", content: strings.Replace(statLine("bash", 52), ")", "", 1), wantErr: true},
+		{name: "not a number", content: strings.Replace(statLine("bash", 52), " 14 ", " x ", 1), wantErr: true},
+		{name: "negative unsigned", content: strings.Replace(statLine("bash", 52), " 22 ", " -22 ", 1), wantErr: true},
+	}
+	for _, c := range cases {
+		stat, err := parseProcStat([]byte(c.content))
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: parseProcStat = %+v, want error", c.name, stat)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: parseProcStat: %v", c.name, err)
+			continue
+		}
+		if *stat != c.want {
+			t.Errorf("%s: parseProcStat = %+v, want %+v", c.name, *stat, c.want)
+		}
+	}
+}
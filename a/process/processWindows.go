@@ -20,7 +20,7 @@ type SYSTEM_TIMES struct {
 	UserTime   syscall.Filetime
 }
 
-func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
+func pidExistsWithContext(ctx context.Context, root cpu.Root, pid int32) (bool, error) {
 	if pid == 0 {
 		return true, nil
 	}
@@ -28,7 +28,7 @@ func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
 		return false, fmt.Errorf("invalid pid %v", pid)
 	}
 	if pid%4 != 0 {
-		pids, err := pidsWithContext(ctx)
+		pids, err := pidsWithContext(ctx, root)
 		if err != nil {
 			return false, err
 		}
@@ -56,7 +56,7 @@ func pidExistsWithContext(ctx context.Context, pid int32) (bool, error) {
 	return exitCode == STILL_ACTIVE, err
 }
 
-func pidsWithCtx(ctx context.Context) ([]int32, error) {
+func pidsWithCtx(ctx context.Context, root cpu.Root) ([]int32, error) {
 	var ret []int32
 	var read uint32 = 0
 	var psSize uint32 = 1024
@@ -139,3 +139,47 @@ func getProcessCPUTimes(pid int32) (SYSTEM_TIMES, error) {
 
 	return times, err
 }
+
+func (p *Process) nameWithContext(ctx context.Context) (string, error) {
+	return "", cpu.ErrorNotImplemented
+}
+
+func (p *Process) cmdlineSliceWithContext(ctx context.Context) ([]string, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) uidsWithContext(ctx context.Context) ([]int32, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) ppidWithContext(ctx context.Context) (int32, error) {
+	return 0, cpu.ErrorNotImplemented
+}
+
+func (p *Process) Stat(ctx context.Context) (*ProcStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) PageFaults(ctx context.Context) (*PageFaultsStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) exeWithContext(ctx context.Context) (string, error) {
+	return "", cpu.ErrorNotImplemented
+}
+
+func (p *Process) cwdWithContext(ctx context.Context) (string, error) {
+	return "", cpu.ErrorNotImplemented
+}
+
+func (p *Process) statusWithContext(ctx context.Context) (Status, error) {
+	return Unknown, cpu.ErrorNotImplemented
+}
+
+func (p *Process) gidsWithContext(ctx context.Context) ([]int32, error) {
+	return nil, cpu.ErrorNotImplemented
+}
+
+func (p *Process) usernameWithContext(ctx context.Context) (string, error) {
+	return "", cpu.ErrorNotImplemented
+}
@@ -0,0 +1,234 @@
+package psi
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Line is one line of a pressure file. The averages are percentages, Total
+// is the accumulated stall time in microseconds.
+type Line struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
+// Stat is the content of /proc/pressure/cpu or a cgroup's cpu.pressure.
+// "some" is the share of time at least one task was waiting for a CPU,
+// "full" the share of time all non-idle tasks were.
+type Stat struct {
+	Some Line `json:"some"`
+	Full Line `json:"full"`
+	// Timestamp is when the file was read.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StallStat is the percentage of wall time stalled between two samples.
+type StallStat struct {
+	Some float64 `json:"some"`
+	Full float64 `json:"full"`
+}
+
+func CPU() (*Stat, error) {
+	return CPUWithContext(context.Background())
+}
+
+func CPUWithContext(ctx context.Context) (*Stat, error) {
+	return CPUWithRoot(ctx, cpu.DefaultRoot())
+}
+
+func CPUWithRoot(ctx context.Context, root cpu.Root) (*Stat, error) {
+	return readPressure(root, root.ProcPath("pressure", "cpu"))
+}
+
+func CgroupCPU() (*Stat, error) {
+	return CgroupCPUWithContext(context.Background())
+}
+
+func CgroupCPUWithContext(ctx context.Context) (*Stat, error) {
+	return CgroupCPUWithRoot(ctx, cpu.DefaultRoot())
+}
+
+// CgroupCPUWithRoot reads cpu.pressure of the current cgroup, which only
+// exists on the unified (v2) hierarchy.
+func CgroupCPUWithRoot(ctx context.Context, root cpu.Root) (*Stat, error) {
+	dir, err := cpu.NewCollector(cpu.WithRoot(root)).CgroupPath("")
+	if err != nil {
+		return nil, err
+	}
+	return readPressure(root, path.Join(dir, "cpu.pressure"))
+}
+
+// Sampler reads the pressure files through a Root and keeps the baselines
+// used by the "since last call" mode of its methods.
+type Sampler struct {
+	root *cpu.Root
+
+	lastCPU    cpu.Baseline[*Stat]
+	lastCgroup cpu.Baseline[*Stat]
+}
+
+type Option func(*Sampler)
+
+// WithRoot makes the sampler read all files through root instead of
+// cpu.DefaultRoot.
+func WithRoot(root cpu.Root) Option {
+	return func(s *Sampler) {
+		s.root = &root
+	}
+}
+
+func NewSampler(opts ...Option) *Sampler {
+	s := &Sampler{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var defaultSampler = NewSampler()
+
+func (s *Sampler) Root() cpu.Root {
+	if s.root == nil {
+		return cpu.DefaultRoot()
+	}
+	return *s.root
+}
+
+// CPUStallWithContext uses the time left until the deadline of ctx as the
+// sampling interval, see CPUStallInterval.
+func CPUStallWithContext(ctx context.Context) (*StallStat, error) {
+	return defaultSampler.CPUStallWithContext(ctx)
+}
+
+func CPUStallInterval(ctx context.Context, interval time.Duration) (*StallStat, error) {
+	return defaultSampler.CPUStallInterval(ctx, interval)
+}
+
+func CgroupCPUStallWithContext(ctx context.Context) (*StallStat, error) {
+	return defaultSampler.CgroupCPUStallWithContext(ctx)
+}
+
+func CgroupCPUStallInterval(ctx context.Context, interval time.Duration) (*StallStat, error) {
+	return defaultSampler.CgroupCPUStallInterval(ctx, interval)
+}
+
+func (s *Sampler) CPUStallWithContext(ctx context.Context) (*StallStat, error) {
+	return s.CPUStallInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
+
+// CPUStallInterval samples /proc/pressure/cpu twice, interval apart, and
+// returns the share of the interval tasks were stalled waiting for a CPU.
+// ctx is only used for cancellation. If interval is 0 or less the values are
+// relative to the previous call.
+func (s *Sampler) CPUStallInterval(ctx context.Context, interval time.Duration) (*StallStat, error) {
+	root := s.Root()
+	return stallInterval(ctx, interval, &s.lastCPU, func() (*Stat, error) {
+		return CPUWithRoot(ctx, root)
+	})
+}
+
+func (s *Sampler) CgroupCPUStallWithContext(ctx context.Context) (*StallStat, error) {
+	return s.CgroupCPUStallInterval(ctx, cpu.GetTimeoutDuration(ctx))
+}
+
+// CgroupCPUStallInterval is CPUStallInterval for the current cgroup.
+func (s *Sampler) CgroupCPUStallInterval(ctx context.Context, interval time.Duration) (*StallStat, error) {
+	root := s.Root()
+	return stallInterval(ctx, interval, &s.lastCgroup, func() (*Stat, error) {
+		return CgroupCPUWithRoot(ctx, root)
+	})
+}
+
+// stallInterval reads twice, interval apart, see cpu.SampleInterval.
+func stallInterval(ctx context.Context, interval time.Duration, last *cpu.Baseline[*Stat], read func() (*Stat, error)) (*StallStat, error) {
+	s1, s2, err := cpu.SampleInterval(ctx, interval, last, read)
+	if err != nil {
+		return nil, err
+	}
+	return CalculateStall(s1.Value, s2.Value)
+}
+
+// CalculateStall returns the stall percentages between s1 and the later
+// sample s2, using the time that passed between reading them. The timestamps
+// are taken after reading, so a fully stalled interval may come out slightly
+// above 100 and is capped. A total that went backwards fails with
+// cpu.ErrorCounterDecreased.
+func CalculateStall(s1, s2 *Stat) (*StallStat, error) {
+	elapsed := s2.Timestamp.Sub(s1.Timestamp).Microseconds()
+	if elapsed <= 0 {
+		return nil, fmt.Errorf("samples are not in order: %v, %v", s1.Timestamp, s2.Timestamp)
+	}
+	if s2.Some.Total < s1.Some.Total || s2.Full.Total < s1.Full.Total {
+		return nil, cpu.ErrorCounterDecreased
+	}
+	percent := func(t1, t2 uint64) float64 {
+		return math.Min(100, float64(t2-t1)/float64(elapsed)*100)
+	}
+	return &StallStat{
+		Some: percent(s1.Some.Total, s2.Some.Total),
+		Full: percent(s1.Full.Total, s2.Full.Total),
+	}, nil
+}
+
+func readPressure(root cpu.Root, name string) (*Stat, error) {
+	lines, err := root.ReadLines(name)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := parsePressure(lines)
+	if err != nil {
+		return nil, err
+	}
+	ret.Timestamp = time.Now()
+	return ret, nil
+}
+
+// parsePressure parses lines like
+// "some avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+func parsePressure(lines []string) (*Stat, error) {
+	ret := &Stat{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var l *Line
+		switch fields[0] {
+		case "some":
+			l = &ret.Some
+		case "full":
+			l = &ret.Full
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("wrong pressure format: %q", line)
+			}
+			var err error
+			switch kv[0] {
+			case "avg10":
+				l.Avg10, err = strconv.ParseFloat(kv[1], 64)
+			case "avg60":
+				l.Avg60, err = strconv.ParseFloat(kv[1], 64)
+			case "avg300":
+				l.Avg300, err = strconv.ParseFloat(kv[1], 64)
+			case "total":
+				l.Total, err = strconv.ParseUint(kv[1], 10, 64)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,61 @@
+package sensors
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+)
+
+// TemperatureStat is one temperature sensor. Temperatures are in degrees
+// Celsius, High and Critical are 0 when the sensor has no such threshold.
+type TemperatureStat struct {
+	// SensorKey identifies the sensor, such as "thermal_zone0" or
+	// "coretemp_temp2".
+	SensorKey   string  `json:"sensorKey"`
+	Label       string  `json:"label"`
+	Temperature float64 `json:"temperature"`
+	High        float64 `json:"high"`
+	Critical    float64 `json:"critical"`
+	// Package and Core are the physical package and core_id the sensor
+	// belongs to, -1 when unknown or the sensor is not per core.
+	Package int `json:"package"`
+	Core    int `json:"core"`
+	// CPUs are the logical CPUs on Core, matched by cpu.InfoStat.CoreID.
+	CPUs []int32 `json:"cpus"`
+}
+
+func Temperatures() ([]TemperatureStat, error) {
+	return TemperaturesWithContext(context.Background())
+}
+
+func TemperaturesWithContext(ctx context.Context) ([]TemperatureStat, error) {
+	return TemperaturesWithRoot(ctx, cpu.DefaultRoot())
+}
+
+func CoreTemperatures() ([]TemperatureStat, error) {
+	return CoreTemperaturesWithContext(context.Background())
+}
+
+func CoreTemperaturesWithContext(ctx context.Context) ([]TemperatureStat, error) {
+	return CoreTemperaturesWithRoot(ctx, cpu.DefaultRoot())
+}
+
+// CoreTemperaturesWithRoot returns only the sensors of a single core.
+func CoreTemperaturesWithRoot(ctx context.Context, root cpu.Root) ([]TemperatureStat, error) {
+	temps, err := TemperaturesWithRoot(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	var ret []TemperatureStat
+	for _, t := range temps {
+		if t.Core >= 0 {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
+// Overheating reports whether the temperature reached the high threshold,
+// where the hardware usually starts throttling.
+func (t TemperatureStat) Overheating() bool {
+	return t.High > 0 && t.Temperature >= t.High
+}
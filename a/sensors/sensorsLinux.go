@@ -0,0 +1,206 @@
+//go:build linux
+// +build linux
+
+package sensors
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpuHwmon are the hwmon drivers reporting CPU temperatures.
+var cpuHwmon = map[string]bool{
+	"coretemp": true,
+	"k10temp":  true,
+}
+
+func TemperaturesWithRoot(ctx context.Context, root cpu.Root) ([]TemperatureStat, error) {
+	ret, err := thermalZones(root)
+	if err != nil {
+		return nil, err
+	}
+	temps, err := hwmonTemperatures(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(temps) == 0 {
+		return ret, nil
+	}
+
+	// the sensors are still useful without the CPU mapping, so an unreadable
+	// cpuinfo is not an error
+	infos, _ := cpu.NewCollector(cpu.WithRoot(root)).InfoWithContext(ctx)
+	for i := range temps {
+		temps[i].CPUs = coreCPUs(infos, temps[i].Package, temps[i].Core)
+	}
+	return append(ret, temps...), nil
+}
+
+func thermalZones(root cpu.Root) ([]TemperatureStat, error) {
+	dirs, err := globSorted(root, root.SysPath("class/thermal/thermal_zone[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []TemperatureStat
+	for _, dir := range dirs {
+		temp, err := readMilliCelsius(root, path.Join(dir, "temp"))
+		if err != nil {
+			// disabled zones fail to read
+			continue
+		}
+		t := TemperatureStat{
+			SensorKey:   path.Base(dir),
+			Temperature: temp,
+			Package:     -1,
+			Core:        -1,
+		}
+		t.Label, _ = root.ReadString(path.Join(dir, "type"))
+
+		trips, _ := root.Glob(path.Join(dir, "trip_point_[0-9]*_type"))
+		for _, trip := range trips {
+			typ, err := root.ReadString(trip)
+			if err != nil {
+				continue
+			}
+			v, err := readMilliCelsius(root, strings.TrimSuffix(trip, "_type")+"_temp")
+			if err != nil || v <= 0 {
+				continue
+			}
+			switch typ {
+			case "hot":
+				t.High = v
+			case "critical":
+				t.Critical = v
+			}
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+func hwmonTemperatures(root cpu.Root) ([]TemperatureStat, error) {
+	dirs, err := globSorted(root, root.SysPath("class/hwmon/hwmon[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []TemperatureStat
+	for _, dir := range dirs {
+		// kernels before 3.15 keep the attributes in the device directory
+		if !root.Exists(path.Join(dir, "name")) {
+			dir = path.Join(dir, "device")
+		}
+		name, err := root.ReadString(path.Join(dir, "name"))
+		if err != nil || !cpuHwmon[name] {
+			continue
+		}
+		inputs, err := globSorted(root, path.Join(dir, "temp[0-9]*_input"))
+		if err != nil {
+			return nil, err
+		}
+
+		pkg := -1
+		var temps []TemperatureStat
+		for _, input := range inputs {
+			prefix := strings.TrimSuffix(input, "_input")
+			temp, err := readMilliCelsius(root, input)
+			if err != nil {
+				continue
+			}
+			t := TemperatureStat{
+				SensorKey:   name + "_" + path.Base(prefix),
+				Temperature: temp,
+				Package:     -1,
+				Core:        -1,
+			}
+			t.Label, _ = root.ReadString(prefix + "_label")
+			t.High, _ = readMilliCelsius(root, prefix+"_max")
+			t.Critical, _ = readMilliCelsius(root, prefix+"_crit")
+
+			// coretemp labels its sensors "Package id 0", "Core 0", ...
+			if n, ok := labelNumber(t.Label, "Package id "); ok {
+				pkg = n
+			} else if n, ok := labelNumber(t.Label, "Core "); ok {
+				t.Core = n
+			}
+			temps = append(temps, t)
+		}
+		for i := range temps {
+			temps[i].Package = pkg
+		}
+		ret = append(ret, temps...)
+	}
+	return ret, nil
+}
+
+// coreCPUs returns the logical CPUs of core on pkg. A pkg of -1 matches any
+// package, which is right on single socket machines.
+func coreCPUs(infos []cpu.InfoStat, pkg, core int) []int32 {
+	if core < 0 {
+		return nil
+	}
+	coreID := strconv.Itoa(core)
+	pkgID := strconv.Itoa(pkg)
+	var ret []int32
+	for _, info := range infos {
+		if info.CoreID != coreID {
+			continue
+		}
+		if pkg >= 0 && info.PhysicalID != "" && info.PhysicalID != pkgID {
+			continue
+		}
+		ret = append(ret, info.CPU)
+	}
+	return ret
+}
+
+func labelNumber(label, prefix string) (int, bool) {
+	if !strings.HasPrefix(label, prefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(label, prefix)))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func readMilliCelsius(root cpu.Root, name string) (float64, error) {
+	value, err := root.ReadString(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / 1000, nil
+}
+
+// globSorted is root.Glob ordered by the number at the end of the names, so
+// that thermal_zone10 comes after thermal_zone2.
+func globSorted(root cpu.Root, pattern string) ([]string, error) {
+	names, err := root.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	number := func(name string) int {
+		base := path.Base(name)
+		base = strings.TrimRight(base, "_abcdefghijklmnopqrstuvwxyz")
+		i := len(base)
+		for i > 0 && base[i-1] >= '0' && base[i-1] <= '9' {
+			i--
+		}
+		n, _ := strconv.Atoi(base[i:])
+		return n
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return number(names[i]) < number(names[j])
+	})
+	return names, nil
+}
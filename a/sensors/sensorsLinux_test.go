@@ -0,0 +1,98 @@
+//go:build linux
+// +build linux
+
+package sensors
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// cpuinfo describes two packages with two hyperthreaded cores each. CPUs 0,
+// 1, 4 and 5 are on package 0, the others on package 1, and the siblings of
+// a core are 4 apart.
+func cpuinfo() string {
+	var b strings.Builder
+	for cpu := 0; cpu < 8; cpu++ {
+		fmt.Fprintf(&b, "processor\t: %d\nphysical id\t: %d\ncore id\t\t: %d\n\n", cpu, cpu/2%2, cpu%2)
+	}
+	return b.String()
+}
+
+func TestTemperaturesFixture(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s + "\n")} }
+	fsys := fstest.MapFS{
+		"proc/cpuinfo": {Data: []byte(cpuinfo())},
+
+		"sys/class/thermal/thermal_zone0/temp":              file("45000"),
+		"sys/class/thermal/thermal_zone0/type":              file("x86_pkg_temp"),
+		"sys/class/thermal/thermal_zone0/trip_point_0_type": file("critical"),
+		"sys/class/thermal/thermal_zone0/trip_point_0_temp": file("100000"),
+
+		// not a CPU sensor
+		"sys/class/hwmon/hwmon0/name":        file("acpitz"),
+		"sys/class/hwmon/hwmon0/temp1_input": file("30000"),
+
+		// package 0 in the device directory, as before 3.15
+		"sys/class/hwmon/hwmon1/device/name":        file("coretemp"),
+		"sys/class/hwmon/hwmon1/device/temp1_input": file("55000"),
+		"sys/class/hwmon/hwmon1/device/temp1_label": file("Package id 0"),
+		"sys/class/hwmon/hwmon1/device/temp2_input": file("50000"),
+		"sys/class/hwmon/hwmon1/device/temp2_label": file("Core 0"),
+		"sys/class/hwmon/hwmon1/device/temp3_input": file("52000"),
+		"sys/class/hwmon/hwmon1/device/temp3_label": file("Core 1"),
+		"sys/class/hwmon/hwmon1/device/temp3_max":   file("80000"),
+		"sys/class/hwmon/hwmon1/device/temp3_crit":  file("100000"),
+
+		// package 1 in the current layout
+		"sys/class/hwmon/hwmon2/name":        file("coretemp"),
+		"sys/class/hwmon/hwmon2/temp1_input": file("60000"),
+		"sys/class/hwmon/hwmon2/temp1_label": file("Package id 1"),
+		"sys/class/hwmon/hwmon2/temp2_input": file("61000"),
+		"sys/class/hwmon/hwmon2/temp2_label": file("Core 1"),
+	}
+
+	temps, err := TemperaturesWithRoot(context.Background(), cpu.NewRoot(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TemperatureStat{
+		{SensorKey: "thermal_zone0", Label: "x86_pkg_temp", Temperature: 45, Critical: 100, Package: -1, Core: -1},
+		{SensorKey: "coretemp_temp1", Label: "Package id 0", Temperature: 55, Package: 0, Core: -1},
+		{SensorKey: "coretemp_temp2", Label: "Core 0", Temperature: 50, Package: 0, Core: 0, CPUs: []int32{0, 4}},
+		{SensorKey: "coretemp_temp3", Label: "Core 1", Temperature: 52, High: 80, Critical: 100, Package: 0, Core: 1, CPUs: []int32{1, 5}},
+		{SensorKey: "coretemp_temp1", Label: "Package id 1", Temperature: 60, Package: 1, Core: -1},
+		{SensorKey: "coretemp_temp2", Label: "Core 1", Temperature: 61, Package: 1, Core: 1, CPUs: []int32{3, 7}},
+	}
+	if !reflect.DeepEqual(temps, want) {
+		t.Errorf("TemperaturesWithRoot =\n%+v\nwant\n%+v", temps, want)
+	}
+}
+
+func TestCoreCPUs(t *testing.T) {
+	infos := []cpu.InfoStat{
+		{CPU: 0, PhysicalID: "0", CoreID: "0"},
+		{CPU: 1, PhysicalID: "1", CoreID: "0"},
+		{CPU: 2, CoreID: "0"},
+	}
+	cases := []struct {
+		pkg, core int
+		want      []int32
+	}{
+		{pkg: 0, core: 0, want: []int32{0, 2}},
+		{pkg: 1, core: 0, want: []int32{1, 2}},
+		{pkg: -1, core: 0, want: []int32{0, 1, 2}},
+		{pkg: 0, core: 1},
+		{pkg: 0, core: -1},
+	}
+	for _, c := range cases {
+		if got := coreCPUs(infos, c.pkg, c.core); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("coreCPUs(%d, %d) = %v, want %v", c.pkg, c.core, got, c.want)
+		}
+	}
+}
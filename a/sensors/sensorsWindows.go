@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package sensors
+
+import (
+	"context"
+	"cpuV3/a/cpu"
+)
+
+func TemperaturesWithRoot(ctx context.Context, root cpu.Root) ([]TemperatureStat, error) {
+	return nil, cpu.ErrorNotImplemented
+}
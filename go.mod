@@ -2,10 +2,13 @@ module cpuV3
 
 go 1.20
 
+require (
+	github.com/tklauser/go-sysconf v0.3.14
+	github.com/yusufpapurcu/wmi v1.2.4
+	golang.org/x/sys v0.25.0
+)
+
 require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
-	github.com/tklauser/go-sysconf v0.3.14 // indirect
 	github.com/tklauser/numcpus v0.8.0 // indirect
-	github.com/yusufpapurcu/wmi v1.2.4 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 )